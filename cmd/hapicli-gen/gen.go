@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ritoon/hapiclient-go/hapicli/alps"
+)
+
+// generator writes the Go code of ALPS profiles: a struct for each
+// semantic descriptor with fields and a method for each transition.
+type generator struct {
+	pkg string
+	// Prepended to the descriptor ids to get the rels, e.g. "ex:".
+	relPrefix string
+	sources   []string
+
+	// The struct types by descriptor, and the top-level names taken.
+	types map[*alps.Descriptor]string
+	names map[string]bool
+	// The transitions, in the order of the documents,
+	// and the names of their methods.
+	transitions []*alps.Descriptor
+	methods     map[*alps.Descriptor]string
+	methodNames map[string]bool
+	profiles    []*alps.Profile
+}
+
+// reserved are the top-level names written by the generator itself.
+var reserved = []string{"Client", "NewClient", "urlVariables"}
+
+func newGenerator(pkg, relPrefix string) *generator {
+	g := &generator{
+		pkg:         pkg,
+		relPrefix:   relPrefix,
+		types:       make(map[*alps.Descriptor]string),
+		names:       make(map[string]bool),
+		methods:     make(map[*alps.Descriptor]string),
+		methodNames: make(map[string]bool),
+	}
+	for _, name := range reserved {
+		g.names[name] = true
+	}
+	return g
+}
+
+// unique returns the name, followed by a number from 2 when it is
+// already taken, and marks it as taken.
+func unique(taken map[string]bool, name string) string {
+	out := name
+	for i := 2; taken[out]; i++ {
+		out = fmt.Sprintf("%s%d", name, i)
+	}
+	taken[out] = true
+	return out
+}
+
+// add adds the descriptors of the profile read from the source.
+func (g *generator) add(source string, p *alps.Profile) {
+	g.sources = append(g.sources, source)
+	g.profiles = append(g.profiles, p)
+	seen := make(map[string]bool)
+	for _, d := range g.transitions {
+		seen[d.ID] = true
+	}
+	var walk func(ds []*alps.Descriptor)
+	walk = func(ds []*alps.Descriptor) {
+		for _, d := range ds {
+			if d.ID == "" {
+				// a reference, generated where it is defined
+				continue
+			}
+			switch {
+			case d.Type != alps.Semantic && d.ID != "" && !seen[d.ID]:
+				seen[d.ID] = true
+				g.transitions = append(g.transitions, d)
+				g.methods[d] = unique(g.methodNames, exported(d.ID))
+			case d.Type == alps.Semantic && len(d.Fields()) > 0:
+				g.typeName(d)
+			}
+			walk(d.Descriptors)
+		}
+	}
+	walk(p.Descriptors)
+}
+
+// typeName returns the name of the struct of a semantic descriptor,
+// registering it the first time.
+func (g *generator) typeName(d *alps.Descriptor) string {
+	if name, ok := g.types[d]; ok {
+		return name
+	}
+	name := unique(g.names, exported(d.FieldName()))
+	g.types[d] = name
+	return name
+}
+
+// rt returns the semantic descriptor returned by the transition, nil
+// when it returns nothing or a descriptor with no fields.
+func (g *generator) rt(d *alps.Descriptor) *alps.Descriptor {
+	if !strings.HasPrefix(d.Rt, "#") {
+		return nil
+	}
+	for _, p := range g.profiles {
+		if r, err := p.Descriptor(d.Rt); err == nil {
+			r = r.Resolved()
+			if r.Type == alps.Semantic && len(r.Fields()) > 0 {
+				return r
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// generate returns the formatted Go source.
+func (g *generator) generate() ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by hapicli-gen from %s. DO NOT EDIT.\n\n", strings.Join(g.sources, ", "))
+	fmt.Fprintf(&b, "package %s\n\n", g.pkg)
+
+	// The bodies of the transitions are generated as types too.
+	bodies := make(map[*alps.Descriptor]string)
+	params := false
+	for _, d := range g.transitions {
+		if len(d.Fields()) == 0 {
+			continue
+		}
+		suffix := "Body"
+		if d.Type == alps.Safe {
+			suffix = "Params"
+			params = true
+		}
+		bodies[d] = unique(g.names, g.methods[d]+suffix)
+	}
+
+	fmt.Fprintln(&b, "import (")
+	if len(g.transitions) > 0 {
+		fmt.Fprintln(&b, "\t\"context\"")
+	}
+	if len(bodies) > 0 {
+		fmt.Fprintln(&b, "\t\"encoding/json\"")
+	}
+	fmt.Fprintln(&b, "\n\t\"github.com/ritoon/hapiclient-go/hapicli\"")
+	if len(g.transitions) > 0 {
+		fmt.Fprintln(&b, "\t\"github.com/ritoon/hapiclient-go/hapicli/hal\"")
+	}
+	fmt.Fprint(&b, `)
+
+// Client sends the requests described by the profiles.
+type Client struct {
+	client *hapicli.Client
+}
+
+// NewClient create a Client sending the requests with c.
+func NewClient(c *hapicli.Client) *Client {
+	return &Client{client: c}
+}
+
+`)
+
+	byName := make(map[string]*alps.Descriptor)
+	var names []string
+	for d, name := range g.types {
+		byName[name] = d
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.writeStruct(&b, name, byName[name], false)
+	}
+	for _, d := range g.transitions {
+		if name, ok := bodies[d]; ok {
+			g.writeStruct(&b, name, d, d.Type == alps.Safe)
+		}
+	}
+	for _, d := range g.transitions {
+		g.writeMethod(&b, d, bodies[d])
+	}
+	if params {
+		fmt.Fprintln(&b, `// urlVariables returns the "name=value" url variables of the params.
+func urlVariables(params interface{}) ([]string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	var vars []string
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			vars = append(vars, k+"="+s)
+			continue
+		}
+		data, _ := json.Marshal(v)
+		vars = append(vars, k+"="+string(data))
+	}
+	return vars, nil
+}`)
+	}
+	return format.Source(b.Bytes())
+}
+
+// writeStruct writes the struct of the fields of the descriptor, the
+// fields of params being the url variables of a safe transition.
+func (g *generator) writeStruct(b *bytes.Buffer, name string, d *alps.Descriptor, params bool) {
+	switch {
+	case params:
+		fmt.Fprintf(b, "// %s are the url variables of %s.\n", name, g.methods[d])
+	case d.Type != alps.Semantic:
+		fmt.Fprintf(b, "// %s is the body of %s.\n", name, g.methods[d])
+	default:
+		fmt.Fprintf(b, "// %s is the %s descriptor.\n", name, d.FieldName())
+	}
+	writeDoc(b, "", d)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	seen := make(map[string]bool)
+	for _, f := range d.Fields() {
+		field := exported(f.FieldName())
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		writeDoc(b, "\t", f)
+		fmt.Fprintf(b, "\t%s %s `json:\"%s,omitempty\"`\n", field, g.fieldType(f), f.FieldName())
+	}
+	fmt.Fprint(b, "}\n\n")
+}
+
+// fieldType returns the Go type of a field: the struct of the descriptor
+// it returns or of its own fields, else the type of its "type" ext.
+func (g *generator) fieldType(f *alps.Descriptor) string {
+	if r := g.rt(f); r != nil {
+		return "*" + g.typeName(r)
+	}
+	if name, ok := g.types[f]; ok {
+		return "*" + name
+	}
+	for _, e := range f.Ext {
+		if e.ID == "type" {
+			switch e.Value {
+			case "string":
+				return "string"
+			case "integer":
+				return "int64"
+			case "number":
+				return "float64"
+			case "boolean":
+				return "bool"
+			}
+		}
+	}
+	return "interface{}"
+}
+
+// writeMethod writes the method sending the request of the transition
+// on its rel, with the body of the given type.
+func (g *generator) writeMethod(b *bytes.Buffer, d *alps.Descriptor, body string) {
+	name := g.methods[d]
+	rel := g.relPrefix + d.ID
+	method := httpMethod(d)
+	rt := g.rt(d)
+
+	fmt.Fprintf(b, "// %s sends a %s on the %s link of the resource.\n", name, method, rel)
+	writeDoc(b, "", d)
+	var params, results string
+	if body != "" {
+		arg := "body"
+		if d.Type == alps.Safe {
+			arg = "params"
+		}
+		params = fmt.Sprintf(", %s *%s", arg, body)
+	}
+	if rt != nil {
+		results = fmt.Sprintf("(*%s, *hal.Resource, error)", g.typeName(rt))
+		fmt.Fprintf(b, "// It returns the %s and its resource.\n", rt.FieldName())
+	} else {
+		results = "(*hal.Resource, error)"
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(ctx context.Context, res *hal.Resource%s) %s {\n", name, params, results)
+
+	fail := "return nil, err"
+	if rt != nil {
+		fail = "return nil, nil, err"
+	}
+	vars, messageBody := "nil", `""`
+	switch {
+	case body != "" && d.Type == alps.Safe:
+		fmt.Fprintf(b, "\tvars, err := urlVariables(params)\n\tif err != nil {\n\t\t%s\n\t}\n", fail)
+		vars = "vars"
+	case body != "":
+		fmt.Fprintf(b, "\tdata, err := json.Marshal(body)\n\tif err != nil {\n\t\t%s\n\t}\n", fail)
+		messageBody = "string(data)"
+	}
+	fmt.Fprintf(b, "\trel, err := hal.NewCustomRel(%q)\n\tif err != nil {\n\t\t%s\n\t}\n", rel, fail)
+	fmt.Fprintf(b, "\tr, err := hapicli.NewRequest(%q, %s, %s, \"\")\n\tif err != nil {\n\t\t%s\n\t}\n", method, vars, messageBody, fail)
+	if rt == nil {
+		fmt.Fprint(b, "\treturn c.client.SendFollow(ctx, res, hapicli.NewFollow(rel, r))\n}\n\n")
+		return
+	}
+	fmt.Fprintf(b, "\tout, err := c.client.SendFollow(ctx, res, hapicli.NewFollow(rel, r))\n\tif err != nil || out == nil {\n\t\t%s\n\t}\n", fail)
+	fmt.Fprintf(b, "\tv := new(%s)\n\tif err := hal.UnmarshalResource(out, v); err != nil {\n\t\treturn nil, nil, err\n\t}\n\treturn v, out, nil\n}\n\n", g.typeName(rt))
+}
+
+// httpMethod returns the method of a transition: GET when safe, POST
+// when unsafe, and PUT when idempotent unless its id starts with
+// delete or remove.
+func httpMethod(d *alps.Descriptor) string {
+	switch d.Type {
+	case alps.Safe:
+		return "GET"
+	case alps.Unsafe:
+		return "POST"
+	}
+	id := strings.ToLower(d.ID)
+	if strings.HasPrefix(id, "delete") || strings.HasPrefix(id, "remove") {
+		return "DELETE"
+	}
+	return "PUT"
+}
+
+// writeDoc writes the title and the text doc of the descriptor as comments.
+func writeDoc(b *bytes.Buffer, indent string, d *alps.Descriptor) {
+	var lines []string
+	if d.Title != "" {
+		lines = append(lines, d.Title)
+	}
+	if d.Doc != nil && d.Doc.Value != "" && (d.Doc.Format == "text" || d.Doc.Format == "markdown") {
+		lines = append(lines, strings.Split(d.Doc.Value, "\n")...)
+	}
+	if d.Doc != nil && d.Doc.Href != "" {
+		lines = append(lines, "see "+d.Doc.Href)
+	}
+	for _, l := range lines {
+		fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimSpace(l))
+	}
+}
+
+// initialisms are written in upper case in the Go names.
+var initialisms = map[string]bool{"api": true, "id": true, "json": true, "url": true, "uri": true, "http": true, "iban": true, "bic": true}
+
+// exported returns the exported Go name of an ALPS id,
+// e.g. CreateDirectDebits for create-direct-debits.
+func exported(id string) string {
+	words := strings.FieldsFunc(id, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b bytes.Buffer
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		rs := []rune(w)
+		rs[0] = unicode.ToUpper(rs[0])
+		b.WriteString(string(rs))
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
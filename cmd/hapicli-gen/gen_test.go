@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	data := []struct {
+		Title  string
+		Args   []string
+		Golden string
+	}{
+		{"A", []string{"-package", "directdebits", "-prefix", "profile:", "testdata/directdebits.json"}, "testdata/directdebits.golden"},
+		{"B", []string{"-package", "orders", "-prefix", "ex:", "testdata/orders.xml"}, "testdata/orders.golden"},
+		{"C", []string{"-package", "collisions", "-prefix", "ex:", "testdata/collisions.json"}, "testdata/collisions.golden"},
+	}
+	for _, v := range data {
+		var stdout, stderr bytes.Buffer
+		if code := run(v.Args, &stdout, &stderr); code != 0 {
+			t.Error("for test", v.Title, "expected exit code 0 got", code, stderr.String())
+			continue
+		}
+		golden, err := ioutil.ReadFile(v.Golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != string(golden) {
+			t.Errorf("for test %s expected\n%s\ngot\n%s", v.Title, golden, stdout.String())
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	data := []struct {
+		Title   string
+		Args    []string
+		OutCode int
+	}{
+		{"A", nil, 2},
+		{"B", []string{"testdata/missing.json"}, 1},
+		{"C", []string{"-package", "a-b", "testdata/directdebits.json"}, 1},
+		{"D", []string{"gen_test.go"}, 1},
+	}
+	for _, v := range data {
+		var stdout, stderr bytes.Buffer
+		if code := run(v.Args, &stdout, &stderr); code != v.OutCode {
+			t.Error("for test", v.Title, "expected exit code", v.OutCode, "got", code, stderr.String())
+		}
+	}
+}
+
+func TestExported(t *testing.T) {
+	data := []struct {
+		In  string
+		Out string
+	}{
+		{"create-direct-debits", "CreateDirectDebits"},
+		{"paymentReference", "PaymentReference"},
+		{"id", "ID"},
+		{"api_url", "APIURL"},
+		{"3ds", "X3ds"},
+	}
+	for _, v := range data {
+		if out := exported(v.In); out != v.Out {
+			t.Error("for", v.In, "expected", v.Out, "got", out)
+		}
+	}
+	if strings.Contains(exported("a.b"), ".") {
+		t.Error("expected a Go identifier")
+	}
+}
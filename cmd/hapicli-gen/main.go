@@ -0,0 +1,77 @@
+// Command hapicli-gen generates a typed Go client from the ALPS profiles
+// of an API: a struct for each semantic descriptor having fields, and a
+// method for each transition following its rel with the hapicli client.
+//
+//	hapicli-gen -package slimpay -prefix "profile:" -o client.go alps.json
+//
+// The create-direct-debits unsafe descriptor gives:
+//
+//	func (c *Client) CreateDirectDebits(ctx context.Context, res *hal.Resource, body *CreateDirectDebitsBody) (*hal.Resource, error)
+//
+// The fields are typed interface{}, unless their descriptor has a "type"
+// ext whose value is string, integer, number or boolean.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ritoon/hapiclient-go/hapicli/alps"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run generates the code and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("hapicli-gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pkg := fs.String("package", "client", "the `name` of the generated package")
+	prefix := fs.String("prefix", "", "the `prefix` of the rels, prepended to the descriptor ids")
+	out := fs.String("o", "", "the `file` to write, the standard output by default")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: hapicli-gen [flags] profile.json|profile.xml...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	g := newGenerator(*pkg, *prefix)
+	for _, file := range fs.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		p, err := alps.Parse(data)
+		if err != nil {
+			fmt.Fprintln(stderr, file+":", err)
+			return 1
+		}
+		g.add(filepath.Base(file), p)
+	}
+	src, err := g.generate()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *out == "" {
+		stdout.Write(src)
+		return 0
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
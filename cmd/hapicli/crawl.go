@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ritoon/hapiclient-go/hapicli"
+	"github.com/ritoon/hapiclient-go/hapicli/crawl"
+)
+
+var errorFormat = errors.New("hapicli: the format must be text, dot or mermaid")
+
+// runCrawl crawls the API from the url of the options
+// and writes its graph in the format of the options.
+func runCrawl(ctx context.Context, opts *options, stdout io.Writer, getenv func(string) string) error {
+	if opts.format != "text" && opts.format != "dot" && opts.format != "mermaid" {
+		return errorFormat
+	}
+	header := hapicli.ParseHeaders(requestHeader(opts))
+	c := crawl.NewCrawler(&http.Client{Transport: headerTransport{header, newTransport(opts, getenv)}})
+	c.SetMaxDepth(opts.depth)
+	g, err := c.Crawl(ctx, opts.url)
+	if err != nil {
+		return err
+	}
+	switch opts.format {
+	case "dot":
+		return g.WriteDOT(stdout)
+	case "mermaid":
+		return g.WriteMermaid(stdout)
+	}
+	return printRelSummary(stdout, g)
+}
+
+// printRelSummary writes what was observed of each rel.
+func printRelSummary(w io.Writer, g *crawl.Graph) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REL\tLINKS\tMEDIA TYPES\tPROFILES\tMETHODS")
+	for _, r := range g.Rels {
+		name := r.Name
+		if r.Deprecated {
+			name += " [deprecated]"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", name, r.Count, list(r.MediaTypes), list(r.Profiles), list(r.Methods))
+	}
+	return tw.Flush()
+}
+
+func list(ss []string) string {
+	if len(ss) == 0 {
+		return "-"
+	}
+	return strings.Join(ss, ",")
+}
+
+// headerTransport adds the headers to each request.
+type headerTransport struct {
+	header http.Header
+	next   http.RoundTripper
+}
+
+func (t headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header)+len(t.header))
+	for k, v := range r.Header {
+		r2.Header[k] = v
+	}
+	for k, v := range t.header {
+		r2.Header[k] = v
+	}
+	return t.next.RoundTrip(r2)
+}
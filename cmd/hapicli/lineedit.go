@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// lineReader reads the lines typed in the shell. A tab completes the
+// line, or lists the completions when there are several of them.
+//
+// When echo is true the terminal is in raw mode: the reader echoes
+// the input and handles the backspaces, ^C and ^D itself.
+type lineReader struct {
+	in       *bufio.Reader
+	out      io.Writer
+	complete func(line string) []string
+	echo     bool
+}
+
+func newLineReader(in io.Reader, out io.Writer, complete func(string) []string) *lineReader {
+	return &lineReader{in: bufio.NewReader(in), out: out, complete: complete}
+}
+
+// ReadLine prints the prompt and reads a line, without its end.
+// throws io.EOF at the end of the input
+func (lr *lineReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(lr.out, prompt)
+	var line []rune
+	for {
+		c, _, err := lr.in.ReadRune()
+		if err == io.EOF && len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch c {
+		case '\n', '\r':
+			if lr.echo {
+				fmt.Fprint(lr.out, "\n")
+			}
+			return string(line), nil
+		case '\t':
+			line = lr.tab(prompt, line)
+		case 0x7f, '\b':
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				lr.print("\b \b")
+			}
+		case 0x03: // ^C
+			line = line[:0]
+			lr.print("^C\n" + prompt)
+		case 0x04: // ^D
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+		default:
+			line = append(line, c)
+			lr.print(string(c))
+		}
+	}
+}
+
+// tab completes the line: to the completion when there is only one, to
+// their common prefix, or else the completions are listed.
+func (lr *lineReader) tab(prompt string, line []rune) []rune {
+	cs := lr.complete(string(line))
+	if len(cs) == 0 {
+		return line
+	}
+	completed := cs[0]
+	if len(cs) > 1 {
+		completed = commonPrefix(cs)
+	}
+	if len(completed) > len(string(line)) || len(cs) == 1 {
+		lr.print(strings.Repeat("\b \b", len(line)) + completed)
+		return []rune(completed)
+	}
+	// Only the last word of the completions is listed.
+	list := make([]string, len(cs))
+	for i, c := range cs {
+		fs := strings.Fields(c)
+		list[i] = fs[len(fs)-1]
+	}
+	fmt.Fprintf(lr.out, "\n%s\n%s%s", strings.Join(list, "  "), prompt, string(line))
+	return line
+}
+
+// print writes s when the reader echoes the input.
+func (lr *lineReader) print(s string) {
+	if lr.echo {
+		fmt.Fprint(lr.out, s)
+	}
+}
+
+func commonPrefix(ss []string) string {
+	p := ss[0]
+	for _, s := range ss[1:] {
+		for !strings.HasPrefix(s, p) {
+			p = p[:len(p)-1]
+		}
+	}
+	return p
+}
@@ -0,0 +1,283 @@
+// Command hapicli browses a HAL API from the command line.
+//
+//	hapicli get https://api.example.com --follow ex:orders --var id=42
+//	hapicli post https://api.example.com --follow ex:create-order --body order.json
+//
+// It prints the state of the resource reached, its links, with the
+// templated and deprecated ones marked, and its embedded rels.
+//
+// The resources can also be explored from an interactive shell,
+// or mapped by a crawl drawing a Graphviz or a Mermaid diagram:
+//
+//	hapicli shell https://api.example.com
+//	hapicli crawl https://api.example.com --depth 3 --format dot | dot -Tsvg > api.svg
+//
+// The authentication is read from the flags or from the environment:
+// a bearer token (--token or HAPICLI_TOKEN) or a user and a password
+// (--user name:password or HAPICLI_USER and HAPICLI_PASSWORD).
+// It is only sent to the scheme and host of the url, not to the other
+// hosts the links lead to.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ritoon/hapiclient-go/hapicli"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+const usage = `usage: hapicli <get|post|put|patch|delete> <url> [flags]
+       hapicli shell <url> [flags]
+       hapicli crawl <url> [flags]
+
+Sends the request to the url, or to the last link followed from it,
+and prints the resource returned. The shell command starts an
+interactive shell on the resource, type help for its commands.
+The crawl command maps the resources reachable from the url.
+
+flags:
+`
+
+var errorUsage = errors.New("hapicli: invalid arguments")
+
+// listFlag is a flag which can be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// options are the arguments of a command.
+type options struct {
+	method  string
+	url     string
+	follows listFlag
+	vars    listFlag
+	headers listFlag
+	body    string
+	token   string
+	user    string
+	strict  bool
+	// The options of the crawl command.
+	depth  int
+	format string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run executes the command line and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	opts, err := parseArgs(args, stderr)
+	if err != nil {
+		return 2
+	}
+	switch opts.method {
+	case "SHELL":
+		err = runShell(context.Background(), opts, stdin, stdout, getenv)
+	case "CRAWL":
+		err = runCrawl(context.Background(), opts, stdout, getenv)
+	default:
+		err = send(context.Background(), opts, stdin, stdout, getenv)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// parseArgs reads the command, its url and its flags,
+// the flags being accepted before and after the url.
+func parseArgs(args []string, stderr io.Writer) (*options, error) {
+	opts := new(options)
+	fs := flag.NewFlagSet("hapicli", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.Var(&opts.follows, "follow", "a `rel` to follow, repeated to follow several links")
+	fs.Var(&opts.vars, "var", "a `name=value` variable of the templated links")
+	fs.Var(&opts.headers, "header", "a `Name: value` header to send")
+	fs.StringVar(&opts.body, "body", "", "the `file` holding the JSON body to send, - for the standard input")
+	fs.StringVar(&opts.token, "token", "", "the bearer `token`, HAPICLI_TOKEN by default")
+	fs.StringVar(&opts.user, "user", "", "the `name:password` of the basic authentication, HAPICLI_USER and HAPICLI_PASSWORD by default")
+	fs.BoolVar(&opts.strict, "strict", false, "reject the HAL JSON responses which are not valid HAL")
+	fs.IntVar(&opts.depth, "depth", 0, "crawl: the maximum number of links followed, no limit when 0")
+	fs.StringVar(&opts.format, "format", "text", "crawl: the `format` of the graph, text, dot or mermaid")
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return nil, errorUsage
+	}
+	opts.method = strings.ToUpper(positional[0])
+	opts.url = positional[1]
+	return opts, nil
+}
+
+// send sends the request described by the options and prints the result.
+func send(ctx context.Context, opts *options, stdin io.Reader, stdout io.Writer, getenv func(string) string) error {
+	body, err := readBody(opts.body, stdin)
+	if err != nil {
+		return err
+	}
+	header := requestHeader(opts)
+	// The intermediate resources are read with a GET, the request
+	// of the command is sent on the last link followed.
+	last, err := hapicli.NewRequest(opts.method, opts.vars, body, header)
+	if err != nil {
+		return err
+	}
+	get, _ := hapicli.NewRequest("GET", opts.vars, "", header)
+
+	c := newClient(opts, getenv)
+	var res *hal.Resource
+	if len(opts.follows) == 0 {
+		res, err = c.Send(ctx, opts.url, last)
+	} else {
+		var follows []*hapicli.Follow
+		if follows, err = newFollows(opts.follows, get, last); err != nil {
+			return err
+		}
+		if res, err = c.Send(ctx, opts.url, get); err == nil {
+			res, err = c.SendFollow(ctx, res, follows...)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return printResource(stdout, res)
+}
+
+// newClient creates the client of the options.
+func newClient(opts *options, getenv func(string) string) *hapicli.Client {
+	c := hapicli.NewClient(&http.Client{Transport: newTransport(opts, getenv)})
+	c.SetAPIURL(opts.url)
+	c.SetStrict(opts.strict)
+	return c
+}
+
+// requestHeader returns the headers sent with each request,
+// one "Name: value" pair per line.
+func requestHeader(opts *options) string {
+	return strings.Join(opts.headers, "\n")
+}
+
+// newTransport returns the transport of the requests, sending the
+// Authorization header of the options to the scheme and host of
+// their url only.
+func newTransport(opts *options, getenv func(string) string) http.RoundTripper {
+	auth := authorization(opts, getenv)
+	if auth == "" {
+		return http.DefaultTransport
+	}
+	t := authTransport{auth: auth, next: http.DefaultTransport}
+	if u, err := url.Parse(opts.url); err == nil {
+		t.scheme, t.host = u.Scheme, u.Host
+	}
+	return t
+}
+
+// newFollows creates the follows of the rels,
+// the last one sending last and the others get.
+func newFollows(rels []string, get, last hapicli.AbstractRequester) ([]*hapicli.Follow, error) {
+	follows := make([]*hapicli.Follow, len(rels))
+	for i, name := range rels {
+		rel, err := hal.NewCustomRel(name)
+		if err != nil {
+			return nil, err
+		}
+		r := get
+		if i == len(rels)-1 {
+			r = last
+		}
+		follows[i] = hapicli.NewFollow(rel, r)
+	}
+	return follows, nil
+}
+
+// readBody reads the body from the file, or from stdin when "-".
+func readBody(file string, stdin io.Reader) (string, error) {
+	var b []byte
+	var err error
+	switch file {
+	case "":
+		return "", nil
+	case "-":
+		b, err = ioutil.ReadAll(stdin)
+	default:
+		b, err = ioutil.ReadFile(file)
+	}
+	return string(b), err
+}
+
+// authorization returns the Authorization header of the
+// options, or of the environment when there is none.
+func authorization(opts *options, getenv func(string) string) string {
+	token, user := opts.token, opts.user
+	if token == "" && user == "" {
+		token = getenv("HAPICLI_TOKEN")
+		if u := getenv("HAPICLI_USER"); u != "" {
+			user = u + ":" + getenv("HAPICLI_PASSWORD")
+		}
+	}
+	switch {
+	case token != "":
+		return "Bearer " + token
+	case user != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user))
+	}
+	return ""
+}
+
+// authTransport sets the Authorization header of the requests sent to
+// the scheme and the host, the credentials are not sent to the other
+// hosts the links may lead to, nor in clear text after an https url.
+type authTransport struct {
+	scheme string
+	host   string
+	auth   string
+	next   http.RoundTripper
+}
+
+func (t authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !strings.EqualFold(r.URL.Scheme, t.scheme) || !strings.EqualFold(r.URL.Host, t.host) {
+		return t.next.RoundTrip(r)
+	}
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header)+1)
+	for k, v := range r.Header {
+		r2.Header[k] = v
+	}
+	r2.Header.Set("Authorization", t.auth)
+	return t.next.RoundTrip(r2)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+	"github.com/ritoon/hapiclient-go/hapicli/haltest"
+)
+
+// newAPIServer serves an entry point linking to the orders.
+func newAPIServer() *haltest.Server {
+	srv := haltest.NewServer()
+	srv.Handle("/", hal.NewBuilder().Self("/").
+		Href("ex:orders", "/orders{?id}", hal.LinkOptionalParam{Templated: true}).
+		Href("ex:old-orders", "/v1/orders", hal.LinkOptionalParam{Deprecation: "/docs/v1", Title: "Old orders"}).
+		MustBuild())
+	srv.Handle("/orders?id=42", hal.NewBuilder().Self("/orders?id=42").Prop("amount", 100).
+		Embed("ex:customer", hal.NewBuilder().Self("/customers/1").MustBuild()).
+		EmbedAll("items", hal.NewBuilder().Prop("sku", "A").MustBuild(), hal.NewBuilder().Prop("sku", "B").MustBuild()).
+		MustBuild())
+	srv.HandleMethod("POST", "/orders", 201, hal.NewBuilder().Self("/orders/2").Prop("amount", 50).MustBuild())
+	return srv
+}
+
+func TestRun(t *testing.T) {
+	srv := newAPIServer()
+	defer srv.Close()
+	dir, _ := ioutil.TempDir("", "hapicli")
+	defer os.RemoveAll(dir)
+	body := filepath.Join(dir, "order.json")
+	ioutil.WriteFile(body, []byte(`{"amount":50}`), 0600)
+
+	data := []struct {
+		Title   string
+		Args    []string
+		Stdin   string
+		Env     map[string]string
+		OutCode int
+		Out     []string
+		OutAuth string
+		OutBody string
+	}{
+		{"A", []string{"get", srv.URL}, "", nil, 0, []string{
+			"State:\n{}",
+			"Links:",
+			"  ex:old-orders  /v1/orders    title=\"Old orders\" [deprecated: /docs/v1]\n",
+			"  ex:orders      /orders{?id}  [templated]\n",
+			"  self           /\n",
+		}, "", ""},
+		{"B", []string{"get", srv.URL, "--follow", "ex:orders", "--var", "id=42", "--token", "secret"}, "", nil, 0, []string{
+			"\"amount\": 100",
+			"Embedded:\n  ex:customer  1 resource\n  items        2 resources\n",
+		}, "Bearer secret", ""},
+		{"C", []string{"--follow", "ex:orders", "post", srv.URL, "--body", body}, "", map[string]string{"HAPICLI_USER": "bob", "HAPICLI_PASSWORD": "pw"}, 0, []string{
+			"\"amount\": 50",
+		}, "Basic Ym9iOnB3", `{"amount":50}`},
+		{"D", []string{"patch", srv.URL + "/orders", "--body", "-"}, `{"amount":1}`, map[string]string{"HAPICLI_TOKEN": "env"}, 1, nil, "Bearer env", `{"amount":1}`},
+		{"E", []string{"get", srv.URL, "--follow", "ex:unknown"}, "", nil, 1, nil, "", ""},
+		{"F", []string{"get"}, "", nil, 2, nil, "", ""},
+		{"G", []string{"head", srv.URL}, "", nil, 1, nil, "", ""},
+	}
+	for _, v := range data {
+		before := len(srv.Requests())
+		var stdout, stderr bytes.Buffer
+		code := run(v.Args, strings.NewReader(v.Stdin), &stdout, &stderr, func(k string) string { return v.Env[k] })
+		if code != v.OutCode {
+			t.Error("for test", v.Title, "expected exit code", v.OutCode, "got", code, stderr.String())
+		}
+		for _, o := range v.Out {
+			if !strings.Contains(stdout.String(), o) {
+				t.Errorf("for test %s expected %q in\n%s", v.Title, o, stdout.String())
+			}
+		}
+		reqs := srv.Requests()[before:]
+		if len(reqs) == 0 {
+			continue
+		}
+		last := reqs[len(reqs)-1]
+		for _, r := range reqs {
+			if auth := r.Header.Get("Authorization"); auth != v.OutAuth {
+				t.Error("for test", v.Title, "expected authorization", v.OutAuth, "got", auth)
+			}
+		}
+		if string(last.Body) != v.OutBody {
+			t.Error("for test", v.Title, "expected body", v.OutBody, "got", string(last.Body))
+		}
+	}
+}
+
+func TestCrawl(t *testing.T) {
+	srv := newAPIServer()
+	defer srv.Close()
+
+	data := []struct {
+		Title   string
+		Args    []string
+		OutCode int
+		Out     []string
+	}{
+		{"A", []string{"crawl", srv.URL, "--token", "secret"}, 0, []string{
+			"REL                         LINKS  MEDIA TYPES  PROFILES  METHODS\n",
+			"ex:old-orders [deprecated]  1      -            -         -\n",
+			"ex:orders                   1      -            -         -\n",
+		}},
+		{"B", []string{"crawl", srv.URL, "--format", "dot"}, 0, []string{"digraph api {", `n0 -> n2 [label="ex:orders {id}"];`}},
+		{"C", []string{"crawl", srv.URL, "--format", "mermaid", "--depth", "1"}, 0, []string{"flowchart LR", `n0 -.->|"ex:old-orders"| n1`}},
+		{"D", []string{"crawl", srv.URL, "--format", "svg"}, 1, nil},
+	}
+	for _, v := range data {
+		var stdout, stderr bytes.Buffer
+		code := run(v.Args, nil, &stdout, &stderr, func(string) string { return "" })
+		if code != v.OutCode {
+			t.Error("for test", v.Title, "expected exit code", v.OutCode, "got", code, stderr.String())
+		}
+		for _, o := range v.Out {
+			if !strings.Contains(stdout.String(), o) {
+				t.Errorf("for test %s expected %q in\n%s", v.Title, o, stdout.String())
+			}
+		}
+	}
+	for _, r := range srv.Requests() {
+		if r.URL == "/" && r.Header.Get("Authorization") == "Bearer secret" {
+			return
+		}
+	}
+	t.Error("expected the token to be sent by the crawler")
+}
+
+func TestAuthorizationHosts(t *testing.T) {
+	other := haltest.NewServer()
+	defer other.Close()
+	other.Handle("/orders/1", hal.NewBuilder().Self("/orders/1").Prop("amount", 10).MustBuild())
+	srv := haltest.NewServer()
+	defer srv.Close()
+	srv.Handle("/", hal.NewBuilder().Self("/").Href("ex:order", other.URL+"/orders/1", hal.LinkOptionalParam{}).MustBuild())
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"get", srv.URL, "--follow", "ex:order", "--token", "secret"}
+	if code := run(args, nil, &stdout, &stderr, func(string) string { return "" }); code != 0 {
+		t.Fatal("expected exit code 0 got", code, stderr.String())
+	}
+	if reqs := srv.Requests(); len(reqs) != 1 || reqs[0].Header.Get("Authorization") != "Bearer secret" {
+		t.Error("expected the token to be sent to the entry point got", reqs)
+	}
+	reqs := other.Requests()
+	if len(reqs) != 1 {
+		t.Fatal("expected 1 request on the other host got", len(reqs))
+	}
+	if auth := reqs[0].Header.Get("Authorization"); auth != "" {
+		t.Error("expected no authorization on the other host got", auth)
+	}
+}
+
+// headerRecorder records the Authorization header of the requests.
+type headerRecorder []string
+
+func (h *headerRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	*h = append(*h, r.Header.Get("Authorization"))
+	return &http.Response{StatusCode: 204, Body: ioutil.NopCloser(strings.NewReader("")), Request: r}, nil
+}
+
+func TestAuthTransport(t *testing.T) {
+	data := []struct {
+		Title   string
+		URL     string
+		OutAuth string
+	}{
+		{"A", "https://api.example.com/orders", "Bearer secret"},
+		{"B", "https://API.example.com/orders", "Bearer secret"},
+		{"C", "http://api.example.com/orders", ""},
+		{"D", "https://api.example.com:8443/orders", ""},
+		{"E", "https://partner.example.com/", ""},
+	}
+	for _, v := range data {
+		var rec headerRecorder
+		tr := authTransport{scheme: "https", host: "api.example.com", auth: "Bearer secret", next: &rec}
+		req, _ := http.NewRequest("GET", v.URL, nil)
+		tr.RoundTrip(req)
+		if len(rec) != 1 || rec[0] != v.OutAuth {
+			t.Error("for test", v.Title, "expected authorization", v.OutAuth, "got", rec)
+		}
+		if req.Header.Get("Authorization") != "" {
+			t.Error("for test", v.Title, "expected the request not to be modified")
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// printResource writes the state, the links and
+// the embedded rels of the resource.
+func printResource(w io.Writer, r *hal.Resource) error {
+	if r == nil {
+		_, err := fmt.Fprintln(w, "No content")
+		return err
+	}
+	if err := printState(w, r); err != nil {
+		return err
+	}
+	return printRels(w, r, "\n")
+}
+
+// printState writes the properties of the resource as indented JSON.
+func printState(w io.Writer, r *hal.Resource) error {
+	state, err := json.MarshalIndent(r.State(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "State:\n%s\n", state)
+	return err
+}
+
+// printRels writes the links and the embedded rels of the
+// resource, each section starting with sep.
+func printRels(w io.Writer, r *hal.Resource, sep string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	links := r.AllLinks()
+	if len(links) > 0 {
+		fmt.Fprintln(tw, sep+"Links:")
+	}
+	for _, rel := range sortedRels(links) {
+		for _, l := range links[rel] {
+			line := "  " + rel + "\t" + l.Href()
+			if m := linkMarkers(&l); m != "" {
+				line += "\t" + m
+			}
+			fmt.Fprintln(tw, line)
+		}
+	}
+	ers, _ := r.AllEmbeddedResources()
+	if len(ers) > 0 {
+		fmt.Fprintln(tw, sep+"Embedded:")
+	}
+	rels := make([]string, 0, len(ers))
+	for rel := range ers {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	for _, rel := range rels {
+		n := len(ers[rel])
+		unit := "resources"
+		if n == 1 {
+			unit = "resource"
+		}
+		fmt.Fprintf(tw, "  %s\t%d %s\n", rel, n, unit)
+	}
+	return tw.Flush()
+}
+
+// linkMarkers returns the markers of a link: its name and title,
+// and whether it is templated or deprecated.
+func linkMarkers(l *hal.Link) string {
+	var m []string
+	if l.Name() != "" {
+		m = append(m, fmt.Sprintf("name=%q", l.Name()))
+	}
+	if l.Title() != "" {
+		m = append(m, fmt.Sprintf("title=%q", l.Title()))
+	}
+	if l.Templated() {
+		m = append(m, "[templated]")
+	}
+	if l.Deprecation() != "" {
+		m = append(m, "[deprecated: "+l.Deprecation()+"]")
+	}
+	return strings.Join(m, " ")
+}
+
+func sortedRels(m map[string][]hal.Link) []string {
+	rels := make([]string, 0, len(m))
+	for rel := range m {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	return rels
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ritoon/hapiclient-go/hapicli"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+const shellHelp = `commands:
+  ls                      list the links and the embedded rels
+  show                    print the state of the resource
+  cd <rel> [name=value]   follow the link, the variables filling a templated link
+  cd <rel>[i]             enter the i-th embedded resource of the rel
+  cd <url>                get the resource at the url
+  back                    return to the previous resource
+  history                 list the resources visited
+  pwd                     print the current resource
+  exit                    leave the shell
+`
+
+var (
+	errorCdUsage    = errors.New("usage: cd <rel> [name=value]...")
+	errorNoPrevious = errors.New("no previous resource")
+)
+
+var shellCommands = []string{"back", "cd", "exit", "help", "history", "ls", "pwd", "show"}
+
+// visit is a resource reached from the shell.
+type visit struct {
+	// The self href of the resource, or how it was reached.
+	name string
+	res  *hal.Resource
+}
+
+// shell explores the resources with the client,
+// from the commands read one per line.
+type shell struct {
+	client *hapicli.Client
+	out    io.Writer
+	// The url variables and the headers of each request.
+	vars   []string
+	header string
+
+	// The resources entered, the current one being the last.
+	stack   []visit
+	history []string
+}
+
+// runShell starts a shell on the resource of the options,
+// reading the commands from stdin until exit or the end of the input.
+func runShell(ctx context.Context, opts *options, stdin io.Reader, stdout io.Writer, getenv func(string) string) error {
+	sh := &shell{
+		client: newClient(opts, getenv),
+		out:    stdout,
+		vars:   opts.vars,
+		header: requestHeader(opts),
+	}
+	if err := sh.get(ctx, opts.url); err != nil {
+		return err
+	}
+	for _, rel := range opts.follows {
+		if err := sh.cd(ctx, []string{rel}); err != nil {
+			return err
+		}
+	}
+
+	lr := newLineReader(stdin, stdout, sh.complete)
+	if f, ok := stdin.(*os.File); ok {
+		if restore, err := rawTerminal(f); err == nil {
+			defer restore()
+			lr.echo = true
+		}
+	}
+	for {
+		line, err := lr.ReadLine(sh.prompt())
+		if err == io.EOF {
+			fmt.Fprintln(stdout)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+		if err := sh.exec(ctx, args); err != nil {
+			fmt.Fprintln(stdout, "error:", err)
+		}
+	}
+}
+
+// exec runs a command of the shell.
+func (sh *shell) exec(ctx context.Context, args []string) error {
+	cur := sh.current()
+	switch args[0] {
+	case "ls":
+		return printRels(sh.out, cur.res, "")
+	case "show":
+		return printState(sh.out, cur.res)
+	case "cd":
+		if len(args) < 2 {
+			return errorCdUsage
+		}
+		return sh.cd(ctx, args[1:])
+	case "back":
+		if len(sh.stack) == 1 {
+			return errorNoPrevious
+		}
+		sh.stack = sh.stack[:len(sh.stack)-1]
+		return nil
+	case "history":
+		for i, h := range sh.history {
+			fmt.Fprintf(sh.out, "%3d  %s\n", i+1, h)
+		}
+		return nil
+	case "pwd":
+		fmt.Fprintln(sh.out, cur.name)
+		return nil
+	case "help":
+		fmt.Fprint(sh.out, shellHelp)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q, type help for the commands", args[0])
+}
+
+// cd enters the resource linked or embedded with the rel, or the
+// resource at the url, args being the target and the url variables.
+func (sh *shell) cd(ctx context.Context, args []string) error {
+	target := args[0]
+	if strings.HasPrefix(target, "/") || strings.Contains(target, "://") {
+		return sh.get(ctx, target)
+	}
+	cur := sh.current()
+	if rel, i, ok := embeddedIndex(target); ok {
+		ers, err := cur.res.EmbeddedResources(rel)
+		if err != nil {
+			return err
+		}
+		if i >= len(ers) {
+			return fmt.Errorf("%s has %d embedded resources", rel, len(ers))
+		}
+		sh.enter(ers[i], target)
+		return nil
+	}
+	if _, err := cur.res.Link(target); err != nil {
+		// An embedded resource alone in its rel needs no index.
+		if er, embErr := cur.res.EmbeddedResource(target); embErr == nil {
+			sh.enter(er, target)
+			return nil
+		}
+		return err
+	}
+	rel, err := hal.NewCustomRel(target)
+	if err != nil {
+		return err
+	}
+	r, err := hapicli.NewRequest("GET", append(sh.vars, args[1:]...), "", sh.header)
+	if err != nil {
+		return err
+	}
+	res, err := sh.client.SendFollow(ctx, cur.res, hapicli.NewFollow(rel, r))
+	if err != nil {
+		return err
+	}
+	sh.enter(res, target)
+	return nil
+}
+
+// get enters the resource at the url.
+func (sh *shell) get(ctx context.Context, url string) error {
+	r, err := hapicli.NewRequest("GET", sh.vars, "", sh.header)
+	if err != nil {
+		return err
+	}
+	res, err := sh.client.Send(ctx, url, r)
+	if err != nil {
+		return err
+	}
+	sh.enter(res, url)
+	return nil
+}
+
+// enter makes res the current resource, named after
+// its self link or else after how it was reached.
+func (sh *shell) enter(res *hal.Resource, reached string) {
+	if res == nil {
+		res = new(hal.Resource)
+	}
+	name := reached
+	if self, err := res.Link(hal.SELF.Name()); err == nil {
+		name = self.Href()
+	} else if len(sh.stack) > 0 {
+		name = sh.current().name + " > " + reached
+	}
+	sh.stack = append(sh.stack, visit{name, res})
+	sh.history = append(sh.history, name)
+}
+
+func (sh *shell) current() visit {
+	return sh.stack[len(sh.stack)-1]
+}
+
+func (sh *shell) prompt() string {
+	return sh.current().name + "> "
+}
+
+// complete returns the completions of the line: the commands,
+// and the rels of the current resource after cd. A CURIE is also
+// completed from its reference, "cd ord" giving "cd ex:orders".
+func (sh *shell) complete(line string) []string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields) == 1 && !strings.HasSuffix(line, " ") {
+		var word string
+		if len(fields) == 1 {
+			word = fields[0]
+		}
+		var out []string
+		for _, c := range shellCommands {
+			if strings.HasPrefix(c, word) {
+				out = append(out, c+" ")
+			}
+		}
+		return out
+	}
+	if fields[0] != "cd" || len(fields) > 2 || len(fields) == 2 && strings.HasSuffix(line, " ") {
+		return nil
+	}
+	var word string
+	if len(fields) == 2 {
+		word = fields[1]
+	}
+	var out []string
+	for _, rel := range sh.rels() {
+		if matchRel(rel, word) {
+			out = append(out, "cd "+rel)
+		}
+	}
+	return out
+}
+
+// rels returns the targets of cd from the current resource: the rels of
+// its links and of its embedded resources, indexed when there are several.
+func (sh *shell) rels() []string {
+	res := sh.current().res
+	var rels []string
+	for rel := range res.AllLinks() {
+		if rel != "curies" {
+			rels = append(rels, rel)
+		}
+	}
+	ers, _ := res.AllEmbeddedResources()
+	for rel, rs := range ers {
+		if len(rs) == 1 {
+			rels = append(rels, rel)
+			continue
+		}
+		for i := range rs {
+			rels = append(rels, rel+"["+strconv.Itoa(i)+"]")
+		}
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+// matchRel tells if the rel starts with the word, or if its CURIE
+// reference does, ignoring the case as the rel lookups do.
+func matchRel(rel, word string) bool {
+	rel, word = strings.ToLower(rel), strings.ToLower(word)
+	if strings.HasPrefix(rel, word) {
+		return true
+	}
+	i := strings.Index(rel, ":")
+	return i > 0 && !strings.HasPrefix(rel[i:], "://") && strings.HasPrefix(rel[i+1:], word)
+}
+
+// embeddedIndex splits a "rel[i]" target.
+func embeddedIndex(target string) (string, int, bool) {
+	open := strings.LastIndex(target, "[")
+	if open <= 0 || !strings.HasSuffix(target, "]") {
+		return "", 0, false
+	}
+	i, err := strconv.Atoi(target[open+1 : len(target)-1])
+	if err != nil || i < 0 {
+		return "", 0, false
+	}
+	return target[:open], i, true
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShell(t *testing.T) {
+	srv := newAPIServer()
+	defer srv.Close()
+
+	script := strings.Join([]string{
+		"ls",
+		"cd ord\t id=42",
+		"show",
+		"cd items[1]",
+		"show",
+		"back",
+		"cd ex:customer",
+		"pwd",
+		"back",
+		"back",
+		"h\tistory",
+		"cd nope",
+		"back",
+		"exit",
+		"show",
+	}, "\n")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"shell", srv.URL}, strings.NewReader(script), &stdout, &stderr, func(string) string { return "" })
+	if code != 0 {
+		t.Fatal("expected exit code 0 got", code, stderr.String())
+	}
+	out := stdout.String()
+	expected := []string{
+		"/> Links:\n  ex:old-orders  /v1/orders",
+		"/orders?id=42> State:\n{\n  \"amount\": 100\n}",
+		"/orders?id=42 > items[1]> State:\n{\n  \"sku\": \"B\"\n}",
+		"/customers/1> /customers/1\n",
+		"\nhelp  history\n/> h",
+		"  1  /\n  2  /orders?id=42\n  3  /orders?id=42 > items[1]\n  4  /customers/1\n",
+		"error: Hal: Rel not found\n",
+		"error: no previous resource\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %q in\n%s", e, out)
+		}
+	}
+	if strings.Count(out, "State:") != 2 {
+		t.Error("expected the shell to stop at exit got", out)
+	}
+}
+
+func TestShellComplete(t *testing.T) {
+	srv := newAPIServer()
+	defer srv.Close()
+	opts, _ := parseArgs([]string{"shell", srv.URL, "--follow", "ex:orders", "--var", "id=42"}, nil)
+	sh := &shell{client: newClient(opts, func(string) string { return "" }), vars: opts.vars}
+	sh.get(context.Background(), opts.url)
+	sh.cd(context.Background(), opts.follows)
+
+	data := []struct {
+		Title string
+		In    string
+		Out   []string
+	}{
+		{"A", "", []string{"back ", "cd ", "exit ", "help ", "history ", "ls ", "pwd ", "show "}},
+		{"B", "s", []string{"show "}},
+		{"C", "cd ", []string{"cd ex:customer", "cd items[0]", "cd items[1]", "cd self"}},
+		{"D", "cd ex:", []string{"cd ex:customer"}},
+		{"E", "cd CUST", []string{"cd ex:customer"}},
+		{"F", "cd items", []string{"cd items[0]", "cd items[1]"}},
+		{"G", "cd self ", nil},
+		{"H", "ls s", nil},
+	}
+	for _, v := range data {
+		if out := sh.complete(v.In); !reflect.DeepEqual(out, v.Out) {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// rawTerminal puts the terminal of f in raw mode with stty, so that the
+// tabs are read as they are typed, and returns the function restoring it.
+// The terminal is also restored when the process is interrupted or
+// terminated, before the signal is delivered again.
+// It fails when f is not a terminal or when stty is not available.
+func rawTerminal(f *os.File) (func(), error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode()&os.ModeCharDevice == 0 {
+		return nil, os.ErrInvalid
+	}
+	stty := func(args ...string) (string, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = f
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	var once sync.Once
+	restore := func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+			stty(saved)
+		})
+	}
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			restore()
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				p.Signal(sig)
+			}
+		case <-done:
+		}
+	}()
+	return restore, nil
+}
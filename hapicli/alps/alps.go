@@ -0,0 +1,460 @@
+// Package alps reads the Application-Level Profile Semantics documents
+// describing the resources of an API: the semantic descriptors of their
+// fields and the transitions (safe, unsafe or idempotent) between them.
+// see https://tools.ietf.org/html/draft-amundsen-richardson-foster-alps-07
+package alps
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrDescriptorNotFound = errors.New("Alps: descriptor not found")
+	errorDocument         = errors.New("Alps: the document must be an ALPS JSON or XML document")
+)
+
+// Type is the type of a descriptor.
+type Type string
+
+const (
+	// Semantic describes a state element, e.g. a field.
+	Semantic Type = "semantic"
+	// Safe describes a transition that does not change the state (GET).
+	Safe Type = "safe"
+	// Unsafe describes a transition that changes the state and is not
+	// idempotent (POST).
+	Unsafe Type = "unsafe"
+	// Idempotent describes a transition that changes the state and is
+	// idempotent (PUT, DELETE).
+	Idempotent Type = "idempotent"
+)
+
+// Profile is an ALPS document.
+type Profile struct {
+	Version     string
+	Doc         *Doc
+	Descriptors []*Descriptor
+	Links       []Link
+	Ext         []Ext
+}
+
+// Descriptor describes an element of the application: a field when it
+// is semantic, or else a transition whose nested semantic descriptors
+// are the fields of its request.
+type Descriptor struct {
+	ID   string
+	Href string
+	Name string
+	// Semantic when not set in the document.
+	Type  Type
+	Title string
+	Def   string
+	Tag   string
+	// The descriptor of the resource returned by a transition.
+	Rt          string
+	Doc         *Doc
+	Descriptors []*Descriptor
+	Links       []Link
+	Ext         []Ext
+
+	// The descriptor of the same document referenced by Href.
+	ref *Descriptor
+}
+
+// Doc is the human readable documentation of a profile or a descriptor.
+type Doc struct {
+	// text, html, asciidoc or markdown.
+	Format string
+	Href   string
+	Value  string
+}
+
+// Link is a link of a profile or a descriptor.
+type Link struct {
+	Rel  string
+	Href string
+}
+
+// Ext is an extension of a profile or a descriptor.
+type Ext struct {
+	ID    string
+	Href  string
+	Value string
+}
+
+// Parse reads an ALPS document, either JSON or XML.
+func Parse(data []byte) (*Profile, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '<' {
+		return ParseXML(data)
+	}
+	return ParseJSON(data)
+}
+
+// Descriptor returns the descriptor with the id, looked up in all the
+// descriptors of the profile, the nested ones included. An id starting
+// with # or an URL with a fragment is looked up by its fragment.
+// throws ErrDescriptorNotFound
+func (p *Profile) Descriptor(id string) (*Descriptor, error) {
+	if i := strings.LastIndex(id, "#"); i >= 0 {
+		id = id[i+1:]
+	}
+	if d := find(p.Descriptors, id); d != nil {
+		return d, nil
+	}
+	return nil, ErrDescriptorNotFound
+}
+
+func find(ds []*Descriptor, id string) *Descriptor {
+	for _, d := range ds {
+		if d.ID == id {
+			return d
+		}
+		if found := find(d.Descriptors, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// resolve links the descriptors referencing an other descriptor of the
+// document with their Href, then types the descriptors without a type:
+// as the descriptor they reference, or else as semantic.
+func (p *Profile) resolve(ds []*Descriptor) {
+	walk(ds, func(d *Descriptor) {
+		if strings.HasPrefix(d.Href, "#") {
+			d.ref = find(p.Descriptors, d.Href[1:])
+		}
+	})
+	walk(ds, func(d *Descriptor) {
+		if d.Type == "" {
+			d.Type = d.Resolved().Type
+		}
+	})
+	walk(ds, func(d *Descriptor) {
+		if d.Type == "" {
+			d.Type = Semantic
+		}
+	})
+}
+
+func walk(ds []*Descriptor, f func(*Descriptor)) {
+	for _, d := range ds {
+		f(d)
+		walk(d.Descriptors, f)
+	}
+}
+
+// Resolved returns the descriptor referenced by Href in the same
+// document, or the descriptor itself when it is not a reference.
+func (d *Descriptor) Resolved() *Descriptor {
+	seen := map[*Descriptor]bool{d: true}
+	for d.ref != nil && !seen[d.ref] {
+		d = d.ref
+		seen[d] = true
+	}
+	return d
+}
+
+// methods are the HTTP methods of each type of descriptor,
+// a semantic descriptor being read like a safe transition.
+var methods = map[Type][]string{
+	Semantic:   {"GET", "HEAD"},
+	Safe:       {"GET", "HEAD"},
+	Unsafe:     {"POST", "PATCH"},
+	Idempotent: {"PUT", "DELETE"},
+}
+
+// Methods returns the HTTP methods of the descriptor:
+// GET or HEAD when it is safe or semantic, POST or PATCH when it is
+// unsafe, PUT or DELETE when it is idempotent.
+func (d *Descriptor) Methods() []string {
+	return append([]string(nil), methods[d.Type]...)
+}
+
+// Fields returns the semantic descriptors nested in the descriptor,
+// or in the descriptor it references, resolved.
+func (d *Descriptor) Fields() []*Descriptor {
+	var fields []*Descriptor
+	for _, f := range d.nested() {
+		if f.Type == Semantic {
+			fields = append(fields, f.Resolved())
+		}
+	}
+	return fields
+}
+
+// Field returns the semantic descriptor nested with the id or the name.
+// throws ErrDescriptorNotFound
+func (d *Descriptor) Field(name string) (*Descriptor, error) {
+	for _, f := range d.Fields() {
+		if f.FieldName() == name {
+			return f, nil
+		}
+	}
+	return nil, ErrDescriptorNotFound
+}
+
+// FieldName returns the name of the field described,
+// its name when it has one or else its id.
+func (d *Descriptor) FieldName() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.ID
+}
+
+// nested returns the nested descriptors, the ones of the referenced
+// descriptor when the descriptor has none.
+func (d *Descriptor) nested() []*Descriptor {
+	if len(d.Descriptors) == 0 {
+		return d.Resolved().Descriptors
+	}
+	return d.Descriptors
+}
+
+// ParseJSON reads an ALPS JSON document.
+func ParseJSON(data []byte) (*Profile, error) {
+	var aux struct {
+		Alps *jsonProfile `json:"alps"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return nil, fmt.Errorf("Alps: invalid JSON document: %v", err)
+	}
+	if aux.Alps == nil {
+		return nil, errorDocument
+	}
+	p := &Profile{Version: aux.Alps.Version, Links: aux.Alps.Links, Ext: aux.Alps.Ext}
+	var err error
+	if p.Doc, err = aux.Alps.Doc.doc(); err != nil {
+		return nil, err
+	}
+	if p.Descriptors, err = aux.Alps.Descriptors.descriptors(); err != nil {
+		return nil, err
+	}
+	p.resolve(p.Descriptors)
+	return p, nil
+}
+
+type jsonProfile struct {
+	Version     string          `json:"version"`
+	Doc         jsonDoc         `json:"doc"`
+	Descriptors jsonDescriptors `json:"descriptor"`
+	Links       jsonLinks       `json:"link"`
+	Ext         jsonExts        `json:"ext"`
+}
+
+type jsonDescriptor struct {
+	ID          string          `json:"id"`
+	Href        string          `json:"href"`
+	Name        string          `json:"name"`
+	Type        Type            `json:"type"`
+	Title       string          `json:"title"`
+	Def         string          `json:"def"`
+	Tag         string          `json:"tag"`
+	Rt          string          `json:"rt"`
+	Doc         jsonDoc         `json:"doc"`
+	Descriptors jsonDescriptors `json:"descriptor"`
+	Links       jsonLinks       `json:"link"`
+	Ext         jsonExts        `json:"ext"`
+}
+
+// jsonDescriptors is an array of descriptors, or a single one.
+type jsonDescriptors json.RawMessage
+
+func (j *jsonDescriptors) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
+
+func (j jsonDescriptors) descriptors() ([]*Descriptor, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	var aux []jsonDescriptor
+	if err := unmarshalList(j, &aux); err != nil {
+		return nil, fmt.Errorf("Alps: invalid descriptor: %v", err)
+	}
+	ds := make([]*Descriptor, len(aux))
+	for i, a := range aux {
+		d := &Descriptor{ID: a.ID, Href: a.Href, Name: a.Name, Type: a.Type, Title: a.Title,
+			Def: a.Def, Tag: a.Tag, Rt: a.Rt, Links: a.Links, Ext: a.Ext}
+		var err error
+		if d.Doc, err = a.Doc.doc(); err != nil {
+			return nil, err
+		}
+		if d.Descriptors, err = a.Descriptors.descriptors(); err != nil {
+			return nil, err
+		}
+		ds[i] = d
+	}
+	return ds, nil
+}
+
+// jsonDoc is a doc object, or a string.
+type jsonDoc json.RawMessage
+
+func (j *jsonDoc) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
+
+func (j jsonDoc) doc() (*Doc, error) {
+	if len(j) == 0 || string(j) == "null" {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(j, &s); err == nil {
+		return &Doc{Format: "text", Value: s}, nil
+	}
+	var aux struct {
+		Format string `json:"format"`
+		Href   string `json:"href"`
+		Value  string `json:"value"`
+	}
+	if err := json.Unmarshal(j, &aux); err != nil {
+		return nil, fmt.Errorf("Alps: invalid doc: %v", err)
+	}
+	if aux.Format == "" {
+		aux.Format = "text"
+	}
+	return &Doc{aux.Format, aux.Href, aux.Value}, nil
+}
+
+// jsonLinks is an array of links, or a single one.
+type jsonLinks []Link
+
+func (j *jsonLinks) UnmarshalJSON(data []byte) error {
+	var aux []struct {
+		Rel  string `json:"rel"`
+		Href string `json:"href"`
+	}
+	if err := unmarshalList(data, &aux); err != nil {
+		return err
+	}
+	*j = make(jsonLinks, len(aux))
+	for i, a := range aux {
+		(*j)[i] = Link{a.Rel, a.Href}
+	}
+	return nil
+}
+
+// jsonExts is an array of extensions, or a single one.
+type jsonExts []Ext
+
+func (j *jsonExts) UnmarshalJSON(data []byte) error {
+	var aux []struct {
+		ID    string `json:"id"`
+		Href  string `json:"href"`
+		Value string `json:"value"`
+	}
+	if err := unmarshalList(data, &aux); err != nil {
+		return err
+	}
+	*j = make(jsonExts, len(aux))
+	for i, a := range aux {
+		(*j)[i] = Ext{a.ID, a.Href, a.Value}
+	}
+	return nil
+}
+
+// unmarshalList decodes an array, or a single
+// element as an array of one element.
+func unmarshalList(data []byte, v interface{}) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '[' {
+		data = append(append([]byte("["), data...), ']')
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ParseXML reads an ALPS XML document.
+func ParseXML(data []byte) (*Profile, error) {
+	var aux xmlDescriptor
+	if err := xml.Unmarshal(data, &aux); err != nil {
+		return nil, fmt.Errorf("Alps: invalid XML document: %v", err)
+	}
+	if aux.XMLName.Local != "alps" {
+		return nil, errorDocument
+	}
+	p := &Profile{Version: aux.Version, Doc: aux.Doc.doc(), Links: aux.links(), Ext: aux.exts()}
+	p.Descriptors = xmlDescriptors(aux.Descriptors)
+	p.resolve(p.Descriptors)
+	return p, nil
+}
+
+// xmlDescriptor is an XML descriptor element, or the alps root element.
+type xmlDescriptor struct {
+	XMLName     xml.Name
+	Version     string          `xml:"version,attr"`
+	ID          string          `xml:"id,attr"`
+	Href        string          `xml:"href,attr"`
+	Name        string          `xml:"name,attr"`
+	Type        Type            `xml:"type,attr"`
+	Title       string          `xml:"title,attr"`
+	Def         string          `xml:"def,attr"`
+	Tag         string          `xml:"tag,attr"`
+	Rt          string          `xml:"rt,attr"`
+	Doc         *xmlDoc         `xml:"doc"`
+	Descriptors []xmlDescriptor `xml:"descriptor"`
+	Links       []struct {
+		Rel  string `xml:"rel,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+	Ext []struct {
+		ID    string `xml:"id,attr"`
+		Href  string `xml:"href,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"ext"`
+}
+
+type xmlDoc struct {
+	Format string `xml:"format,attr"`
+	Href   string `xml:"href,attr"`
+	Value  string `xml:",chardata"`
+}
+
+func (x *xmlDoc) doc() *Doc {
+	if x == nil {
+		return nil
+	}
+	d := &Doc{x.Format, x.Href, strings.TrimSpace(x.Value)}
+	if d.Format == "" {
+		d.Format = "text"
+	}
+	return d
+}
+
+func (x xmlDescriptor) links() []Link {
+	var ls []Link
+	for _, l := range x.Links {
+		ls = append(ls, Link{l.Rel, l.Href})
+	}
+	return ls
+}
+
+func (x xmlDescriptor) exts() []Ext {
+	var es []Ext
+	for _, e := range x.Ext {
+		es = append(es, Ext{e.ID, e.Href, e.Value})
+	}
+	return es
+}
+
+func xmlDescriptors(xs []xmlDescriptor) []*Descriptor {
+	if len(xs) == 0 {
+		return nil
+	}
+	ds := make([]*Descriptor, len(xs))
+	for i, x := range xs {
+		ds[i] = &Descriptor{ID: x.ID, Href: x.Href, Name: x.Name, Type: x.Type, Title: x.Title,
+			Def: x.Def, Tag: x.Tag, Rt: x.Rt, Doc: x.Doc.doc(), Links: x.links(), Ext: x.exts(),
+			Descriptors: xmlDescriptors(x.Descriptors)}
+	}
+	return ds
+}
@@ -0,0 +1,173 @@
+package alps
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func loadProfile(t *testing.T, name string) *Profile {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		t.Fatal("for", name, "expected no error got", err)
+	}
+	return p
+}
+
+func TestParse(t *testing.T) {
+	for _, name := range []string{"orders.json", "orders.xml"} {
+		p := loadProfile(t, name)
+		if p.Version != "1.0" || p.Doc == nil || p.Doc.Value != "The orders of the shop." || p.Doc.Format != "text" {
+			t.Error("for", name, "expected the doc of the profile got", p.Version, p.Doc)
+		}
+		if !reflect.DeepEqual(p.Links, []Link{{"self", "https://api.example.com/alps"}}) {
+			t.Error("for", name, "expected the self link got", p.Links)
+		}
+		if len(p.Descriptors) != 7 {
+			t.Fatal("for", name, "expected 7 descriptors got", len(p.Descriptors))
+		}
+
+		create, err := p.Descriptor("https://api.example.com/alps#create-orders")
+		if err != nil {
+			t.Fatal("for", name, "expected create-orders got", err)
+		}
+		if create.Type != Unsafe || create.Rt != "#order" || create.Title != "Create an order" {
+			t.Error("for", name, "expected the attributes of create-orders got", create)
+		}
+		if *create.Doc != (Doc{"html", "https://dev.example.com/orders", ""}) {
+			t.Error("for", name, "expected the doc of create-orders got", create.Doc)
+		}
+		if !reflect.DeepEqual(fieldNames(create.Fields()), []string{"amount", "reference"}) {
+			t.Error("for", name, "expected the fields of create-orders got", fieldNames(create.Fields()))
+		}
+		if f, err := create.Field("amount"); err != nil || f.Doc.Value != "The amount in cents." {
+			t.Error("for", name, "expected the amount field got", f, err)
+		}
+		if _, err := create.Field("ref"); err != ErrDescriptorNotFound {
+			t.Error("for", name, "expected", ErrDescriptorNotFound, "got", err)
+		}
+
+		update, _ := p.Descriptor("#update-orders")
+		if !reflect.DeepEqual(fieldNames(update.Fields()), []string{"amount", "label", "customer"}) {
+			t.Error("for", name, "expected the fields of the order got", fieldNames(update.Fields()))
+		}
+		if !reflect.DeepEqual(update.Ext, []Ext{{"since", "", "2.0"}}) {
+			t.Error("for", name, "expected the ext of update-orders got", update.Ext)
+		}
+		label, _ := p.Descriptor("label")
+		if label.Type != Semantic {
+			t.Error("for", name, "expected label to be semantic got", label.Type)
+		}
+		if _, err := p.Descriptor("delete-orders"); err != ErrDescriptorNotFound {
+			t.Error("for", name, "expected", ErrDescriptorNotFound, "got", err)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	p := loadProfile(t, "orders.json")
+	data := []struct {
+		Title string
+		ID    string
+		Out   []string
+	}{
+		{"A", "get-orders", []string{"GET", "HEAD"}},
+		{"B", "create-orders", []string{"POST", "PATCH"}},
+		{"C", "update-orders", []string{"PUT", "DELETE"}},
+		{"D", "notify", []string{"GET", "HEAD"}},
+		{"E", "amount", []string{"GET", "HEAD"}},
+	}
+	for _, v := range data {
+		d, err := p.Descriptor(v.ID)
+		if err != nil {
+			t.Error("for test", v.Title, "expected no error got", err)
+			continue
+		}
+		if out := d.Methods(); !reflect.DeepEqual(out, v.Out) {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	data := []struct {
+		Title string
+		In    string
+	}{
+		{"A", `{"descriptor":[]}`},
+		{"B", `<profile/>`},
+		{"C", `{"alps":{"descriptor":[{"id":1}]}}`},
+		{"D", `<alps><descriptor>`},
+		{"E", ``},
+	}
+	for _, v := range data {
+		if _, err := Parse([]byte(v.In)); err == nil {
+			t.Error("for test", v.Title, "expected an error got nil")
+		}
+	}
+}
+
+func fieldNames(ds []*Descriptor) []string {
+	var names []string
+	for _, d := range ds {
+		names = append(names, d.FieldName())
+	}
+	return names
+}
+
+func TestValidateRequest(t *testing.T) {
+	p, err := ParseJSON([]byte(`{"alps":{"descriptor":[
+		{"id":"amount","ext":[{"id":"type","value":"number"},{"id":"required","value":"true"}]},
+		{"id":"creditor","descriptor":[{"id":"reference","ext":[{"id":"required","value":"true"},{"id":"type","value":"string"}]}]},
+		{"id":"create-orders","type":"unsafe","descriptor":[
+			{"href":"#amount"},
+			{"href":"#creditor"},
+			{"id":"items","descriptor":[{"id":"sku"},{"id":"quantity","ext":{"id":"type","value":"integer"}}]},
+			{"id":"express","ext":{"id":"type","value":"boolean"}}
+		]},
+		{"id":"get-orders","type":"safe"},
+		{"id":"cancel-order","type":"idempotent"}
+	]}}`))
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	data := []struct {
+		Title  string
+		ID     string
+		Method string
+		Body   string
+		Out    []string
+	}{
+		{"A", "create-orders", "POST", `{"amount":10,"creditor":{"reference":"c1"},"items":[{"sku":"A","quantity":2}],"express":true}`, nil},
+		{"B", "create-orders", "PUT", `{"amount":10}`, []string{"the method PUT is not allowed by the unsafe descriptor create-orders"}},
+		{"C", "create-orders", "POST", `{"creditor":{},"label":"x"}`, []string{
+			"/amount: is required",
+			"/creditor/reference: is required",
+			"/label: is not described by create-orders",
+		}},
+		{"D", "create-orders", "PATCH", `{"amount":"10","express":"yes","items":[{"quantity":1.5},{"color":"red"}]}`, []string{
+			"/amount: must be of type number",
+			"/express: must be of type boolean",
+			"/items/0/quantity: must be of type integer",
+			"/items/1/color: is not described by items",
+		}},
+		{"E", "create-orders", "POST", `[1]`, []string{"/: must be an object"}},
+		{"F", "create-orders", "POST", `{`, []string{"the body is not JSON: unexpected end of JSON input"}},
+		{"G", "get-orders", "GET", ``, nil},
+		{"H", "get-orders", "DELETE", ``, []string{"the method DELETE is not allowed by the safe descriptor get-orders"}},
+		{"I", "cancel-order", "DELETE", `{"any":1}`, nil},
+		{"J", "amount", "GET", `1`, nil},
+		{"K", "get-orders", "HEAD", ``, nil},
+		{"L", "amount", "POST", ``, []string{"the method POST is not allowed by the semantic descriptor amount"}},
+	}
+	for _, v := range data {
+		d, _ := p.Descriptor(v.ID)
+		if out := d.ValidateRequest(v.Method, []byte(v.Body)); !reflect.DeepEqual(out, v.Out) {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+}
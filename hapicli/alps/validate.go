@@ -0,0 +1,128 @@
+package alps
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExtValue returns the value of the extension with the id, or an empty string.
+func (d *Descriptor) ExtValue(id string) string {
+	for _, e := range d.Ext {
+		if e.ID == id {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// Required tells if the field must be in the requests,
+// which is set with a "required" ext whose value is true.
+func (d *Descriptor) Required() bool {
+	return d.ExtValue("required") == "true"
+}
+
+// AllowsMethod tells if the HTTP method is one of the Methods of the descriptor.
+func (d *Descriptor) AllowsMethod(method string) bool {
+	method = strings.ToUpper(method)
+	for _, m := range methods[d.Type] {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRequest checks the method and the JSON body of a request sent
+// on the transition. Each problem is reported with the JSON Pointer of
+// the faulty property: a method the transition does not allow, a field
+// which is not described, a required field missing, or a value whose
+// type is not the one of the "type" ext (string, integer, number or
+// boolean). The fields are only checked when the transition has some.
+func (d *Descriptor) ValidateRequest(method string, body []byte) []string {
+	var problems []string
+	if !d.AllowsMethod(method) {
+		problems = append(problems, fmt.Sprintf("the method %s is not allowed by the %s descriptor %s", strings.ToUpper(method), d.Type, d.ID))
+	}
+	if len(d.Fields()) == 0 || len(strings.TrimSpace(string(body))) == 0 {
+		return problems
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return append(problems, fmt.Sprintf("the body is not JSON: %v", err))
+	}
+	return append(problems, validateFields(d, "", v)...)
+}
+
+// validateFields checks the value against the fields of the descriptor.
+func validateFields(d *Descriptor, ptr string, v interface{}) []string {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("%s: must be an object", pointer(ptr))}
+	}
+	var problems []string
+	fields := make(map[string]*Descriptor)
+	for _, f := range d.Fields() {
+		fields[f.FieldName()] = f
+		if _, ok := obj[f.FieldName()]; !ok && f.Required() {
+			problems = append(problems, fmt.Sprintf("%s/%s: is required", ptr, f.FieldName()))
+		}
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f, ok := fields[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s/%s: is not described by %s", ptr, name, d.FieldName()))
+			continue
+		}
+		problems = append(problems, validateValue(f, ptr+"/"+name, obj[name])...)
+	}
+	return problems
+}
+
+// validateValue checks a property against its descriptor.
+func validateValue(f *Descriptor, ptr string, v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	if vs, ok := v.([]interface{}); ok && f.ExtValue("type") != "array" {
+		var problems []string
+		for i, item := range vs {
+			problems = append(problems, validateValue(f, fmt.Sprintf("%s/%d", ptr, i), item)...)
+		}
+		return problems
+	}
+	if len(f.Fields()) > 0 {
+		return validateFields(f, ptr, v)
+	}
+	var valid bool
+	switch t := f.ExtValue("type"); t {
+	case "string":
+		_, valid = v.(string)
+	case "number":
+		_, valid = v.(float64)
+	case "integer":
+		n, ok := v.(float64)
+		valid = ok && n == float64(int64(n))
+	case "boolean":
+		_, valid = v.(bool)
+	default:
+		return nil
+	}
+	if !valid {
+		return []string{fmt.Sprintf("%s: must be of type %s", ptr, f.ExtValue("type"))}
+	}
+	return nil
+}
+
+func pointer(ptr string) string {
+	if ptr == "" {
+		return "/"
+	}
+	return ptr
+}
@@ -0,0 +1,379 @@
+// Package cassette provides an http.RoundTripper recording the exchanges
+// with a server into a fixture file, and replaying them in the tests.
+//
+//	rec, err := cassette.New("testdata/orders.json", cassette.Replay, nil)
+//	client := hapicli.NewClient(&http.Client{Transport: rec})
+//
+// The fixture is a human-readable JSON document, the JSON bodies being
+// written as JSON. The requests are matched by method, URL and body.
+// A JSON body is replayed compacted, its members in the recorded order
+// and its numbers as they were written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mode tells if the Recorder records or replays the exchanges.
+type Mode int
+
+const (
+	// Replay answers the requests with the recorded responses.
+	Replay Mode = iota
+	// Record sends the requests and records the exchanges.
+	Record
+)
+
+const redacted = "REDACTED"
+
+// Interaction is a recorded exchange.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	// The body, in JSON when it is a JSON document, as text otherwise.
+	JSON json.RawMessage `json:"json,omitempty"`
+	Body string          `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int             `json:"status"`
+	Headers    http.Header     `json:"headers,omitempty"`
+	JSON       json.RawMessage `json:"json,omitempty"`
+	Body       string          `json:"body,omitempty"`
+}
+
+// cassette is the content of a fixture file.
+type cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is the record-and-replay http.RoundTripper.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	redactedHeaders []string
+	redactedFields  map[string]bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New create a Recorder
+// - param path	string				The fixture file
+// - param mode	Mode				Record or Replay
+// - param rt	http.RoundTripper	Sends the recorded requests (http.DefaultTransport when nil)
+//
+// In Replay mode the fixture file is read and must exist.
+func New(path string, mode Mode, rt http.RoundTripper) (*Recorder, error) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	r := &Recorder{
+		path:            path,
+		mode:            mode,
+		transport:       rt,
+		redactedHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		redactedFields:  make(map[string]bool),
+	}
+	if mode == Record {
+		return r, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cassette: %s is not a valid fixture: %v", path, err)
+	}
+	r.interactions = c.Interactions
+	r.used = make([]bool, len(c.Interactions))
+	return r, nil
+}
+
+// SetRedactedHeaders is setting the headers whose value is not recorded,
+// in addition to the credentials (Authorization, Cookie...)
+func (r *Recorder) SetRedactedHeaders(hs ...string) {
+	r.redactedHeaders = append(r.redactedHeaders, hs...)
+}
+
+// SetRedactedFields is setting the fields of the JSON bodies
+// whose value is not recorded, e.g. "iban" or "access_token"
+func (r *Recorder) SetRedactedFields(fs ...string) {
+	for _, f := range fs {
+		r.redactedFields[strings.ToLower(f)] = true
+	}
+}
+
+// RoundTrip records or replays the exchange.
+// In Replay mode a request which was not recorded is an error
+// describing the request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recReq := r.request(req, body)
+	if r.mode == Replay {
+		return r.replay(req, recReq)
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	recResp := Response{StatusCode: resp.StatusCode, Headers: r.header(resp.Header)}
+	recResp.JSON, recResp.Body = r.body(respBody)
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{recReq, recResp})
+	r.used = append(r.used, true)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Save writes the recorded interactions to the fixture file.
+func (r *Recorder) Save() error {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	r.mu.Lock()
+	err := e.Encode(cassette{r.interactions})
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, b.Bytes(), 0644)
+}
+
+// Unused returns the recorded interactions which have not been replayed.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Interaction
+	for i, u := range r.used {
+		if !u {
+			out = append(out, r.interactions[i])
+		}
+	}
+	return out
+}
+
+// replay answers with the first unused interaction matching the request.
+func (r *Recorder) replay(req *http.Request, recReq Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || !match(in.Request, recReq) {
+			continue
+		}
+		r.used[i] = true
+		body := []byte(in.Response.Body)
+		if in.Response.JSON != nil {
+			var b bytes.Buffer
+			json.Compact(&b, in.Response.JSON)
+			body = b.Bytes()
+		}
+		// The recorded length may not be the one of the replayed body.
+		h := make(http.Header, len(in.Response.Headers))
+		for k, v := range in.Response.Headers {
+			h[k] = v
+		}
+		h.Del("Content-Length")
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        h,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette: no interaction recorded in %s for %s %s %s",
+		r.path, recReq.Method, recReq.URL, describeBody(recReq))
+}
+
+// match tells if the recorded request a matches b,
+// whatever the order of the members of their JSON bodies.
+func match(a, b Request) bool {
+	return a.Method == b.Method && a.URL == b.URL &&
+		a.Body == b.Body && reflect.DeepEqual(jsonValue(a.JSON), jsonValue(b.JSON))
+}
+
+// jsonValue returns the value of the JSON document, its numbers kept
+// as they are written, or nil.
+func jsonValue(data json.RawMessage) interface{} {
+	if data == nil {
+		return nil
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// request returns the redacted record of the request.
+func (r *Recorder) request(req *http.Request, body []byte) Request {
+	out := Request{Method: req.Method, URL: req.URL.String(), Headers: r.header(req.Header)}
+	out.JSON, out.Body = r.body(body)
+	return out
+}
+
+// header returns a copy of h without the redacted values.
+func (r *Recorder) header(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		out[k] = v
+	}
+	for _, k := range r.redactedHeaders {
+		if out.Get(k) != "" {
+			out.Set(k, redacted)
+		}
+	}
+	return out
+}
+
+// body returns the redacted JSON of the body, compacted,
+// or the body as text when it is not JSON.
+func (r *Recorder) body(b []byte) (json.RawMessage, string) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, ""
+	}
+	var out bytes.Buffer
+	if err := json.Compact(&out, b); err != nil {
+		return nil, string(b)
+	}
+	if len(r.redactedFields) == 0 {
+		return json.RawMessage(out.Bytes()), ""
+	}
+	compact := out.Bytes()
+	out = bytes.Buffer{}
+	d := json.NewDecoder(bytes.NewReader(compact))
+	d.UseNumber()
+	if err := r.redact(d, &out); err != nil {
+		return nil, string(b)
+	}
+	return json.RawMessage(out.Bytes()), ""
+}
+
+// redact writes the JSON value read from the decoder, keeping the order
+// of the members, the values of the redacted fields being replaced.
+func (r *Recorder) redact(d *json.Decoder, out *bytes.Buffer) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return writeToken(out, tok)
+	}
+	out.WriteString(delim.String())
+	for i := 0; d.More(); i++ {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if delim == '{' {
+			key, err := d.Token()
+			if err != nil {
+				return err
+			}
+			if err := writeToken(out, key); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+			if k, _ := key.(string); r.redactedFields[strings.ToLower(k)] {
+				var skipped json.RawMessage
+				if err := d.Decode(&skipped); err != nil {
+					return err
+				}
+				if err := writeToken(out, redacted); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := r.redact(d, out); err != nil {
+			return err
+		}
+	}
+	end, err := d.Token()
+	if err != nil {
+		return err
+	}
+	out.WriteString(end.(json.Delim).String())
+	return nil
+}
+
+// writeToken writes a JSON scalar, the numbers as they are written.
+func writeToken(out *bytes.Buffer, tok json.Token) error {
+	if n, ok := tok.(json.Number); ok {
+		out.WriteString(n.String())
+		return nil
+	}
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(tok); err != nil {
+		return err
+	}
+	out.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+	return nil
+}
+
+// readBody reads the body of the request and returns a copy of the
+// request whose body can be read again, the request being left as is.
+func readBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil {
+		return req, nil, nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	out := new(http.Request)
+	*out = *req
+	out.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return out, b, nil
+}
+
+func describeBody(r Request) string {
+	if r.JSON != nil {
+		return string(r.JSON)
+	}
+	return r.Body
+}
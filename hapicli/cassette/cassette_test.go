@@ -0,0 +1,193 @@
+package cassette
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, `{"method":"%s","received":%s,"access_token":"secret"}`, r.Method, string(body))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+
+	send := func(rec *Recorder, iban string) (*hal.Resource, error) {
+		c := hapicli.NewClient(&http.Client{Transport: rec})
+		r, _ := hapicli.NewRequest("POST", nil, `{"iban":"`+iban+`","amount":100}`, "Authorization: Bearer secret")
+		return c.Send(context.Background(), srv.URL+"/orders", r)
+	}
+
+	rec, err := New(path, Record, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.SetRedactedFields("iban", "access_token")
+	if _, err := send(rec, "FR7630006000011234567890189"); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	fixture, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{"FR7630006000011234567890189", "secret"} {
+		if strings.Contains(string(fixture), secret) {
+			t.Error("expected", secret, "to be redacted in", string(fixture))
+		}
+	}
+
+	rec, err = New(path, Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.SetRedactedFields("iban", "access_token")
+	res, err := send(rec, "DE89370400440532013000")
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if hits != 1 {
+		t.Error("expected the replay not to hit the server got", hits, "hits")
+	}
+	if res.State()["method"] != "POST" || res.State()["access_token"] != redacted {
+		t.Error("expected the recorded response got", res.State())
+	}
+	if len(rec.Unused()) != 0 {
+		t.Error("expected all the interactions to be used got", rec.Unused())
+	}
+}
+
+func TestReplayFixture(t *testing.T) {
+	create, _ := hal.NewCustomRel("ex:create-orders")
+
+	data := []struct {
+		Title  string
+		Body   string
+		OutErr bool
+	}{
+		{"A", `{"iban":"FR7630006000011234567890189","label":"The label"}`, false},
+		{"B", `{"iban":"FR7630006000011234567890189","label":"Another label"}`, true},
+	}
+	for _, v := range data {
+		rec, err := New("testdata/orders.json", Replay, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec.SetRedactedFields("iban")
+		c := hapicli.NewClient(&http.Client{Transport: rec})
+		c.SetAPIURL("https://api.example.com/")
+		post, _ := hapicli.NewRequest("POST", nil, v.Body, "")
+		res, err := c.SendFollow(context.Background(), nil, hapicli.NewFollow(create, post))
+		if (err != nil) != v.OutErr {
+			t.Error("for test", v.Title, "expected error", v.OutErr, "got", err)
+		}
+		if err == nil && res.State()["id"] != float64(1) {
+			t.Error("for test", v.Title, "expected order 1 got", res.State())
+		}
+		if err != nil && !strings.Contains(err.Error(), "no interaction recorded in testdata/orders.json for POST") {
+			t.Error("for test", v.Title, "expected an unmatched request error got", err)
+		}
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	if _, err := New("testdata/missing.json", Replay, nil); err == nil {
+		t.Error("expected an error for a missing fixture")
+	}
+}
+
+func TestReplayRawBody(t *testing.T) {
+	const sent = `{"z":1, "id":12345678901234567890,"token":{"v":[1,2]},"a":"<b>"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Header().Set("Content-Length", fmt.Sprint(len(sent)))
+		fmt.Fprint(w, sent)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+
+	get := func(rec *Recorder) (*http.Response, string) {
+		body := ioutil.NopCloser(strings.NewReader(`{"q":1}`))
+		req, _ := http.NewRequest("POST", srv.URL, nil)
+		req.Body = body
+		resp, err := rec.RoundTrip(req)
+		if err != nil {
+			t.Fatal("expected no error got", err)
+		}
+		if req.Body != body {
+			t.Error("expected the request not to be modified")
+		}
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	rec, _ := New(path, Record, nil)
+	rec.SetRedactedFields("token")
+	if _, b := get(rec); b != sent {
+		t.Error("expected the response to be passed as is got", b)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = New(path, Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, b := get(rec)
+	if out := `{"z":1,"id":12345678901234567890,"token":"REDACTED","a":"<b>"}`; b != out {
+		t.Error("expected", out, "got", b)
+	}
+	if resp.ContentLength != int64(len(b)) || resp.Header.Get("Content-Length") != "" {
+		t.Error("expected the length of the replayed body got", resp.ContentLength, resp.Header.Get("Content-Length"))
+	}
+}
+
+func TestRecordRequestUnchanged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	rec, _ := New(filepath.Join(os.TempDir(), "unused.json"), Record, nil)
+	body := ioutil.NopCloser(strings.NewReader(`{"q":1}`))
+	req, _ := http.NewRequest("POST", srv.URL, nil)
+	req.Body = body
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if req.Body != body {
+		t.Error("expected the request not to be modified")
+	}
+	if b, _ := ioutil.ReadAll(resp.Body); string(b) != `{"q":1}` {
+		t.Error("expected the body to be sent got", string(b))
+	}
+}
@@ -0,0 +1,340 @@
+package hapicli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ritoon/hapiclient-go/hapicli/alps"
+	"github.com/ritoon/hapiclient-go/hapicli/collectionjson"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+	"github.com/ritoon/hapiclient-go/hapicli/siren"
+)
+
+// The media types accepted by the client, HAL being preferred.
+const accept = "application/hal+json, application/prs.hal-forms+json, application/json, " +
+	hal.MediaTypeXML + ";q=0.8, " + siren.MediaType + ";q=0.8, " + collectionjson.MediaType + ";q=0.8"
+
+var (
+	errorNoSelf    = errors.New("Hapicli: the resource has no self link")
+	errorMediaType = errors.New("Hapicli: the response is not a HAL, HAL+XML, Siren, Collection+JSON or JSON document")
+)
+
+// Client is sending the requests to a HAL API
+// and building the resources from the responses.
+type Client struct {
+	httpClient *http.Client
+
+	// The URL the relative hrefs are resolved against.
+	apiURL string
+
+	// When true the unsafe requests sent on a resource carry
+	// the validators of the resource (If-Match, If-Unmodified-Since).
+	conditional bool
+
+	// The number of times an idempotent request is sent again,
+	// and the delay before, multiplied by the number of the attempt.
+	maxRetries int
+	retryDelay time.Duration
+
+	// The minimum time between two requests, and the time
+	// the next one may be sent.
+	rateMu      sync.Mutex
+	interval    time.Duration
+	nextRequest time.Time
+
+	// The decoder of the response bodies.
+	decoder *hal.Decoder
+
+	// When true the requests sent on a link with a profile are
+	// checked against the ALPS descriptor of the link.
+	validateProfiles bool
+
+	logger             Logger
+	tracer             Tracer
+	metrics            Metrics
+	interceptors       []Interceptor
+	deprecationHandler DeprecationHandler
+	// The fields of the JSON bodies whose value is not logged.
+	redactedFields map[string]bool
+
+	// The deprecations already notified
+	// and the profiles already fetched.
+	mu           sync.Mutex
+	deprecations map[Deprecation]bool
+	profiles     map[string]*alps.Profile
+}
+
+// NewClient create a Client
+// - param c	*http.Client	The client sending the requests (http.DefaultClient when nil)
+//
+// The authentication is carried by the http.Client, e.g. an oauth2 client.
+func NewClient(c *http.Client) *Client {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	cl := &Client{httpClient: c, decoder: hal.NewDecoder(), logger: stdLogger{}, metrics: noopMetrics{}, retryDelay: defaultRetryDelay}
+	cl.SetRedactedFields()
+	return cl
+}
+
+// SetAPIURL is setting the URL the relative hrefs are resolved against
+func (c *Client) SetAPIURL(u string) {
+	c.apiURL = u
+}
+
+// SetLogger is setting the logger of the client,
+// the standard logger of the log package is used by default
+func (c *Client) SetLogger(l Logger) {
+	c.logger = l
+}
+
+// SetConditional is enabling the optimistic concurrency control:
+// the PUT, PATCH and DELETE requests sent with SendResource carry
+// an If-Match header with the ETag of the resource, or an
+// If-Unmodified-Since header with its Last-Modified date.
+func (c *Client) SetConditional(b bool) {
+	c.conditional = b
+}
+
+// SetStrict is making the client reject the responses which are
+// not valid HAL documents with a *hal.ValidationError.
+// Only the HAL JSON responses are checked, the HAL+XML, Siren and
+// Collection+JSON ones are read the same way in strict mode
+func (c *Client) SetStrict(b bool) {
+	c.decoder.SetStrict(b)
+}
+
+// SetProfileValidation is making the client check the requests sent
+// with SendFollow against the ALPS profile of the followed link before
+// sending them: the method must match the type of the descriptor and the
+// JSON body must hold its required fields and no unknown ones.
+// A request which does not follow its profile returns an *ErrInvalidRequest.
+// When the profile cannot be fetched the request is sent anyway.
+func (c *Client) SetProfileValidation(b bool) {
+	c.validateProfiles = b
+}
+
+// Send sends the request to the given URL.
+// return	*hal.Resource	The resource built from the response body (nil if empty)
+// throws *ErrResponse
+func (c *Client) Send(ctx context.Context, u string, r AbstractRequester) (*hal.Resource, error) {
+	return c.send(ctx, &call{url: u, request: r})
+}
+
+// SendResource sends the request to the self link of the given resource.
+// When the client is conditional and the method is unsafe, a
+// 412 Precondition Failed response returns an *ErrPreconditionFailed
+// holding the current representation of the resource.
+// throws *ErrPreconditionFailed
+// throws *ErrResponse
+func (c *Client) SendResource(ctx context.Context, res *hal.Resource, r AbstractRequester) (*hal.Resource, error) {
+	self, err := res.Link(hal.SELF.Name())
+	if err != nil {
+		return nil, errorNoSelf
+	}
+	h := make(http.Header)
+	if c.conditional && isUnsafe(r.Method()) {
+		switch {
+		case res.ETag() != "":
+			h.Set("If-Match", res.ETag())
+		case res.LastModified() != "":
+			h.Set("If-Unmodified-Since", res.LastModified())
+		}
+	}
+	out, err := c.send(ctx, &call{url: self.Href(), request: r, rel: hal.SELF.Name(), link: self, header: h})
+	if e, ok := err.(*ErrResponse); ok && e.StatusCode == http.StatusPreconditionFailed && len(h) > 0 {
+		// fetch the representation that won the race
+		get := &Request{method: "GET"}
+		fresh, ferr := c.send(ctx, &call{url: self.Href(), request: get, rel: hal.SELF.Name(), link: self})
+		if ferr != nil {
+			return nil, ferr
+		}
+		return nil, &ErrPreconditionFailed{Resource: fresh}
+	}
+	return out, err
+}
+
+// call is a request sent by the client, with the link it follows if any.
+type call struct {
+	url     string
+	request AbstractRequester
+	rel     string
+	link    *hal.Link
+	// The headers added by the client to the ones of the request.
+	header http.Header
+	// The number of the attempt, from 1.
+	attempt int
+}
+
+// send sends the request of the call and builds
+// the resource from the response.
+func (c *Client) send(ctx context.Context, cl *call) (*hal.Resource, error) {
+	resp, body, err := c.do(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	return c.newResource(resp, body)
+}
+
+// do sends the request of the call, retrying it if needed,
+// and returns the successful response with its body.
+// throws *ErrResponse
+func (c *Client) do(ctx context.Context, cl *call) (*http.Response, []byte, error) {
+	u, err := c.resolve(cl.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		resp *http.Response
+		body []byte
+	)
+	for cl.attempt = 1; ; cl.attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return nil, nil, err
+		}
+		resp, body, err = c.roundTrip(ctx, cl, u)
+		if !c.retry(cl, resp, err) {
+			break
+		}
+		c.metrics.Retried(cl.request.Method(), cl.rel)
+		select {
+		case <-time.After(time.Duration(cl.attempt) * c.retryDelay):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	c.deprecatedResponse(u, resp.Header)
+	if resp.StatusCode >= 400 {
+		return nil, nil, &ErrResponse{StatusCode: resp.StatusCode, Body: body}
+	}
+	return resp, body, nil
+}
+
+// roundTrip builds the http.Request of the call,
+// sends it and reads the response.
+func (c *Client) roundTrip(ctx context.Context, cl *call, u string) (resp *http.Response, body []byte, err error) {
+	r := cl.request
+	ctx, span := c.startSpan(ctx, "HTTP "+r.Method())
+	defer func() {
+		if resp != nil {
+			span.SetAttribute(AttrStatusCode, resp.StatusCode)
+		}
+		span.End(err)
+	}()
+	span.SetAttribute(AttrMethod, r.Method())
+	span.SetAttribute(AttrURL, u)
+	span.SetAttribute(AttrAttempt, cl.attempt)
+	if cl.rel != "" {
+		span.SetAttribute(AttrRel, cl.rel)
+	}
+	if cl.link != nil {
+		span.SetAttribute(AttrLinkName, cl.link.Name())
+		span.SetAttribute(AttrTemplated, cl.link.Templated())
+	}
+
+	req, err := http.NewRequest(r.Method(), u, strings.NewReader(r.MessageBody()))
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+	if tp := span.TraceParent(); tp != "" {
+		req.Header.Set("traceparent", tp)
+	}
+	req.Header.Set("Accept", accept)
+	if len(r.MessageBody()) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range ParseHeaders(r.Headers()) {
+		req.Header[k] = v
+	}
+	for k, v := range cl.header {
+		req.Header[k] = v
+	}
+
+	c.logRequest(cl, req)
+	c.metrics.RequestStarted(req.Method, cl.rel)
+	start := time.Now()
+	e := &Exchange{Request: r, Rel: cl.rel, Link: cl.link, Attempt: cl.attempt, HTTPRequest: req}
+	resp, err = c.intercept(e)
+	req = e.HTTPRequest
+	if err == nil {
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	d := time.Since(start)
+	c.metrics.RequestDone(req.Method, cl.rel, statusClass(resp, err), d)
+	if resp != nil {
+		c.metrics.Cached(req.Method, cl.rel, isCached(resp))
+	}
+	c.logResponse(cl, req, resp, body, d, err)
+	return resp, body, err
+}
+
+// newResource builds the resource from the response body
+// and keeps the validators sent by the server.
+// The HAL+XML, Siren and Collection+JSON documents are read
+// into the same model as the HAL ones.
+func (c *Client) newResource(resp *http.Response, body []byte) (*hal.Resource, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var (
+		res *hal.Resource
+		err error
+	)
+	switch mt {
+	case "", "application/hal+json", "application/prs.hal-forms+json", "application/json":
+		res, err = c.decoder.Decode(body)
+	case hal.MediaTypeXML:
+		res, err = hal.NewResourceFromXML(body)
+	case siren.MediaType:
+		res, err = siren.Decode(body)
+	case collectionjson.MediaType:
+		res, err = collectionjson.Decode(body)
+	default:
+		return nil, errorMediaType
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.SetETag(resp.Header.Get("ETag"))
+	res.SetLastModified(resp.Header.Get("Last-Modified"))
+	return res, nil
+}
+
+// resolve returns the absolute URL of href, relative to the API URL.
+func (c *Client) resolve(href string) (string, error) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	if ref.IsAbs() || c.apiURL == "" {
+		return ref.String(), nil
+	}
+	base, err := url.Parse(c.apiURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// isUnsafe tells if the method may change the state of the resource.
+func isUnsafe(method string) bool {
+	switch method {
+	case "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
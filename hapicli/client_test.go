@@ -0,0 +1,196 @@
+package hapicli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// newVersionedServer serves a single HAL resource at /mandates/1
+// whose ETag changes each time it is modified.
+func newVersionedServer() *httptest.Server {
+	version := 1
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if r.Method != "GET" {
+			if im := r.Header.Get("If-Match"); im != "" && im != etag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			ioutil.ReadAll(r.Body)
+			version++
+			etag = fmt.Sprintf(`"v%d"`, version)
+		}
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		fmt.Fprintf(w, `{"_links":{"self":{"href":"%s/mandates/1"}},"version":%d}`, srv.URL, version)
+	}))
+	return srv
+}
+
+func TestSend(t *testing.T) {
+	srv := newVersionedServer()
+	defer srv.Close()
+
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	r, _ := NewRequest("GET", nil, "", "")
+	res, err := c.Send(context.Background(), "/mandates/1", r)
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if res.ETag() != `"v1"` {
+		t.Error("expected", `"v1"`, "got", res.ETag())
+	}
+	if res.LastModified() != "Wed, 21 Oct 2015 07:28:00 GMT" {
+		t.Error("expected the Last-Modified date got", res.LastModified())
+	}
+	if v := res.State()["version"]; v != float64(1) {
+		t.Error("expected version 1 got", v)
+	}
+}
+
+func TestSendResource(t *testing.T) {
+	data := []struct {
+		Title       string
+		Conditional bool
+		Concurrent  bool
+		OutVersion  float64
+		OutFailed   bool
+	}{
+		{"A", true, false, 2, false},
+		{"B", true, true, 2, true},
+		{"C", false, true, 3, false},
+	}
+	for _, v := range data {
+		srv := newVersionedServer()
+		c := NewClient(nil)
+		c.SetConditional(v.Conditional)
+		get, _ := NewRequest("GET", nil, "", "")
+		put, _ := NewRequest("PUT", nil, `{"label":"new"}`, "")
+
+		res, err := c.Send(context.Background(), srv.URL+"/mandates/1", get)
+		if err != nil {
+			t.Fatal("for test", v.Title, "expected no error got", err)
+		}
+		if v.Concurrent {
+			// another client updates the resource in between
+			NewClient(nil).SendResource(context.Background(), res, put)
+		}
+
+		out, err := c.SendResource(context.Background(), res, put)
+		pf, failed := err.(*ErrPreconditionFailed)
+		if failed != v.OutFailed {
+			t.Error("for test", v.Title, "expected precondition failed", v.OutFailed, "got", err)
+		}
+		if failed {
+			out = pf.Resource
+		}
+		if out == nil {
+			t.Error("for test", v.Title, "expected a resource got", err)
+		} else if version := out.State()["version"]; version != v.OutVersion {
+			t.Error("for test", v.Title, "expected version", v.OutVersion, "got", version)
+		}
+		srv.Close()
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	h := ParseHeaders("X-Tenant: acme\r\nIf-None-Match: \"v1\"\nmalformed")
+	if h.Get("X-Tenant") != "acme" || h.Get("If-None-Match") != `"v1"` || len(h) != 2 {
+		t.Error("expected 2 headers got", h)
+	}
+}
+
+func TestSetStrict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/hal+json")
+		fmt.Fprint(w, `{"_links":{"self":{"title":"no href"}}}`)
+	}))
+	defer srv.Close()
+
+	for _, strict := range []bool{false, true} {
+		c := NewClient(nil)
+		c.SetStrict(strict)
+		r, _ := NewRequest("GET", nil, "", "")
+		_, err := c.Send(context.Background(), srv.URL, r)
+		if _, ok := err.(*hal.ValidationError); ok != strict {
+			t.Error("for strict", strict, "expected a validation error", strict, "got", err)
+		}
+	}
+
+	siren := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.siren+json")
+		fmt.Fprint(w, `{"properties":{"id":1},"_links":{"self":{"title":"no href"}}}`)
+	}))
+	defer siren.Close()
+	c := NewClient(nil)
+	c.SetStrict(true)
+	r, _ := NewRequest("GET", nil, "", "")
+	if _, err := c.Send(context.Background(), siren.URL, r); err != nil {
+		t.Error("expected the Siren response not to be checked got", err)
+	}
+}
+
+func TestSendMediaTypes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hal":
+			w.Header().Set("Content-Type", "application/hal+json")
+			fmt.Fprint(w, `{"_links":{"next":{"href":"/orders/2"}}}`)
+		case "/siren":
+			w.Header().Set("Content-Type", "application/vnd.siren+json")
+			fmt.Fprint(w, `{"links":[{"rel":["next"],"href":"/orders/2"}]}`)
+		case "/xml":
+			w.Header().Set("Content-Type", "application/hal+xml")
+			fmt.Fprint(w, `<resource href="/xml"><link rel="next" href="/orders/2"/></resource>`)
+		case "/collection":
+			w.Header().Set("Content-Type", "application/vnd.collection+json; charset=utf-8")
+			fmt.Fprint(w, `{"collection":{"links":[{"rel":"next","href":"/orders/2"}]}}`)
+		case "/orders/2":
+			w.Header().Set("Content-Type", "application/hal+json")
+			fmt.Fprint(w, `{"id":2}`)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html></html>`)
+		}
+	}))
+	defer srv.Close()
+
+	next, _ := hal.NewCustomRel("next")
+	data := []struct {
+		Title  string
+		Path   string
+		OutErr error
+	}{
+		{"A", "/hal", nil},
+		{"B", "/siren", nil},
+		{"C", "/collection", nil},
+		{"D", "/xml", nil},
+		{"E", "/html", errorMediaType},
+	}
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	for _, v := range data {
+		r, _ := NewRequest("GET", nil, "", "")
+		res, err := c.Send(context.Background(), v.Path, r)
+		if err != v.OutErr {
+			t.Error("for test", v.Title, "expected", v.OutErr, "got", err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		out, err := c.SendFollow(context.Background(), res, NewFollow(next, nil))
+		if err != nil || out.State()["id"] != float64(2) {
+			t.Error("for test", v.Title, "expected to follow the next link got", out, err)
+		}
+	}
+}
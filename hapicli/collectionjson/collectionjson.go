@@ -0,0 +1,173 @@
+// Package collectionjson reads the Collection+JSON documents into the
+// resources of the hal package, so the same navigation code works on
+// Collection+JSON and HAL APIs: the items become the embedded resources
+// of the item rel, the links become links and the queries and the
+// write template become HAL-FORMS templates.
+// see http://amundsen.com/media-types/collection/format/
+package collectionjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// MediaType is the content type of the Collection+JSON documents.
+const MediaType = "application/vnd.collection+json"
+
+// ItemRel is the rel of the embedded items.
+const ItemRel = "item"
+
+var errorNoCollection = errors.New("Collectionjson: the document must have a collection object")
+
+type document struct {
+	Collection *collection `json:"collection"`
+}
+
+type collection struct {
+	Version  string                 `json:"version"`
+	Href     string                 `json:"href"`
+	Links    []link                 `json:"links"`
+	Items    []item                 `json:"items"`
+	Queries  []query                `json:"queries"`
+	Template *template              `json:"template"`
+	Error    map[string]interface{} `json:"error"`
+}
+
+type link struct {
+	Rel    string `json:"rel"`
+	Href   string `json:"href"`
+	Name   string `json:"name"`
+	Prompt string `json:"prompt"`
+	Render string `json:"render"`
+}
+
+type item struct {
+	Href  string  `json:"href"`
+	Data  []datum `json:"data"`
+	Links []link  `json:"links"`
+}
+
+type query struct {
+	Rel    string  `json:"rel"`
+	Href   string  `json:"href"`
+	Name   string  `json:"name"`
+	Prompt string  `json:"prompt"`
+	Data   []datum `json:"data"`
+}
+
+type template struct {
+	Data []datum `json:"data"`
+}
+
+type datum struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Prompt string      `json:"prompt,omitempty"`
+}
+
+// Decode builds the resource of a Collection+JSON document.
+// The href of the collection is its self link and the data of an item
+// are the state of its resource. The error of the collection is kept as
+// the error property.
+// A query is a GET template whose key is the name of the query, or its
+// rel. The write template is the default template, POST on the collection.
+// throws errorNoCollection
+func Decode(data []byte) (*hal.Resource, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Collectionjson: the document must be a JSON object : %v", err)
+	}
+	c := doc.Collection
+	if c == nil {
+		return nil, errorNoCollection
+	}
+
+	r := new(hal.Resource)
+	if err := addLinks(r, c.Href, c.Links); err != nil {
+		return nil, err
+	}
+	if c.Version != "" {
+		r.SetProperty("version", c.Version)
+	}
+	if c.Error != nil {
+		r.SetProperty("error", c.Error)
+	}
+	items := make([]*hal.Resource, len(c.Items))
+	for i, it := range c.Items {
+		items[i] = new(hal.Resource)
+		if err := addLinks(items[i], it.Href, it.Links); err != nil {
+			return nil, err
+		}
+		for _, d := range it.Data {
+			items[i].SetProperty(d.Name, d.Value)
+		}
+	}
+	if len(items) > 0 {
+		r.AddEmbeddedResources(ItemRel, items...)
+	}
+	for _, q := range c.Queries {
+		key := q.Name
+		if key == "" {
+			key = q.Rel
+		}
+		r.AddTemplate(key, &hal.Template{Title: q.Prompt, Method: "GET", Target: q.Href, Properties: properties(q.Data)})
+	}
+	if c.Template != nil {
+		r.AddTemplate(hal.DefaultTemplate, &hal.Template{
+			Method:      "POST",
+			ContentType: MediaType,
+			Target:      c.Href,
+			Properties:  properties(c.Template.Data),
+		})
+	}
+	return r, nil
+}
+
+// addLinks adds the self link of href, if any, and the links.
+func addLinks(r *hal.Resource, href string, ls []link) error {
+	if href != "" {
+		self, err := hal.NewLink(href, hal.LinkOptionalParam{})
+		if err != nil {
+			return err
+		}
+		r.AddLink(hal.SELF.Name(), *self)
+	}
+	for _, l := range ls {
+		hl, err := hal.NewLink(l.Href, hal.LinkOptionalParam{Name: l.Name, Title: l.Prompt})
+		if err != nil {
+			return err
+		}
+		r.AddLink(l.Rel, *hl)
+	}
+	return nil
+}
+
+// properties returns the template properties of the data.
+func properties(data []datum) []hal.Property {
+	var out []hal.Property
+	for _, d := range data {
+		out = append(out, hal.Property{Name: d.Name, Prompt: d.Prompt, Value: d.Value})
+	}
+	return out
+}
+
+// TemplateBody returns the Collection+JSON write template
+// filled with the values, sorted by name.
+func TemplateBody(values map[string]interface{}) ([]byte, error) {
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	t := template{Data: make([]datum, 0, len(names))}
+	for _, k := range names {
+		t.Data = append(t.Data, datum{Name: k, Value: values[k]})
+	}
+	return json.Marshal(struct {
+		Template template `json:"template"`
+	}{t})
+}
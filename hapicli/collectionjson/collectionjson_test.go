@@ -0,0 +1,73 @@
+package collectionjson
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestDecode(t *testing.T) {
+	data, _ := ioutil.ReadFile("testdata/friends.json")
+	r, err := Decode(data)
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if self, _ := r.Link("self"); self == nil || self.Href() != "http://example.org/friends/" {
+		t.Error("expected the href as self link got", self)
+	}
+	if feed, _ := r.Link("feed"); feed == nil || feed.Href() != "http://example.org/friends/rss" {
+		t.Error("expected the feed link got", feed)
+	}
+
+	items, err := r.EmbeddedResources(ItemRel)
+	if err != nil || len(items) != 2 {
+		t.Fatal("expected 2 items got", items, err)
+	}
+	if items[0].State()["full-name"] != "J. Doe" || items[1].State()["email"] != "msmith@example.org" {
+		t.Error("expected the data of the items got", items[0].State(), items[1].State())
+	}
+	if blog, _ := items[0].Link("blog"); blog == nil || blog.Title() != "Blog" {
+		t.Error("expected the blog link got", blog)
+	}
+
+	search, err := r.Template("search")
+	if err != nil || search.Method != "GET" || search.Target != "http://example.org/friends/search" || search.Property("search") == nil {
+		t.Error("expected the search query got", search, err)
+	}
+	write, err := r.Template(hal.DefaultTemplate)
+	if err != nil || write.Method != "POST" || write.ContentType != MediaType || len(write.Properties) != 2 {
+		t.Error("expected the write template got", write, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	data := []struct {
+		Title string
+		In    string
+	}{
+		{"A", `{}`},
+		{"B", `[]`},
+		{"C", `{"collection":{"items":[{"href":""}],"links":[{"rel":"a","href":""}]}}`},
+	}
+	for _, v := range data {
+		if _, err := Decode([]byte(v.In)); err == nil {
+			t.Error("for test", v.Title, "expected an error")
+		}
+	}
+	r, err := Decode([]byte(`{"collection":{"error":{"title":"Server Error","code":"X1"}}}`))
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if e, ok := r.State()["error"].(map[string]interface{}); !ok || e["code"] != "X1" {
+		t.Error("expected the error property got", r.State())
+	}
+}
+
+func TestTemplateBody(t *testing.T) {
+	out, err := TemplateBody(map[string]interface{}{"full-name": "W. Chandry", "email": "wchandry@example.org"})
+	expected := `{"template":{"data":[{"name":"email","value":"wchandry@example.org"},{"name":"full-name","value":"W. Chandry"}]}}`
+	if err != nil || string(out) != expected {
+		t.Error("expected", expected, "got", string(out), err)
+	}
+}
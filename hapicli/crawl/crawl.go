@@ -0,0 +1,546 @@
+// Package crawl maps the surface of a HAL API: starting from the entry
+// point, it follows the links breadth-first with GET requests only and
+// draws the graph of the resources reached and of the rels between them.
+//
+//	c := crawl.NewCrawler(nil)
+//	c.SetMaxDepth(3)
+//	c.SetRateLimit(10)
+//	g, err := c.Crawl(ctx, "https://api.example.com")
+//	for _, rel := range g.Rels {
+//		fmt.Println(rel.Name, rel.MediaTypes, rel.Methods)
+//	}
+package crawl
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ritoon/hapiclient-go/hapicli"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+var errorRedirectHost = errors.New("Crawl: the redirect leads out of the allowed hosts")
+
+// Crawler walks an API with a hapicli client.
+type Crawler struct {
+	client *hapicli.Client
+
+	// Limits of the crawl, no limit when 0.
+	maxDepth     int
+	maxResources int
+	// The hosts which are crawled, the host of the entry point when empty.
+	hosts map[string]bool
+}
+
+// NewCrawler create a Crawler
+// - param c	*http.Client	The client sending the requests (http.DefaultClient when nil)
+//
+// The authentication is carried by the http.Client, e.g. an oauth2 client.
+// The crawler sends the requests with a copy of c which does not follow
+// the redirects leading out of the allowed hosts.
+func NewCrawler(c *http.Client) *Crawler {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	hc := *c
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if hosts, ok := req.Context().Value(hostsKey{}).(map[string]bool); ok && !hosts[strings.ToLower(req.URL.Host)] {
+			return errorRedirectHost
+		}
+		if c.CheckRedirect != nil {
+			return c.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("Crawl: stopped after 10 redirects")
+		}
+		return nil
+	}
+	cr := &Crawler{client: hapicli.NewClient(&hc)}
+	cr.client.SetInterceptors(hapicli.InterceptorFunc(record))
+	return cr
+}
+
+// Client returns the client of the crawler, to set its logger,
+// its tracer or its metrics. Its interceptors must not be replaced.
+func (cr *Crawler) Client() *hapicli.Client {
+	return cr.client
+}
+
+// SetMaxDepth is setting the maximum number of links followed from the entry point
+func (cr *Crawler) SetMaxDepth(n int) {
+	cr.maxDepth = n
+}
+
+// SetMaxResources is setting the maximum number of resources fetched
+func (cr *Crawler) SetMaxResources(n int) {
+	cr.maxResources = n
+}
+
+// SetRateLimit is setting the maximum number of requests per second,
+// the rate limit of the client of the crawler
+func (cr *Crawler) SetRateLimit(perSecond float64) {
+	cr.client.SetRateLimit(perSecond)
+}
+
+// SetAllowedHosts is setting the hosts whose resources are fetched,
+// only the host of the entry point by default
+func (cr *Crawler) SetAllowedHosts(hosts ...string) {
+	cr.hosts = make(map[string]bool)
+	for _, h := range hosts {
+		cr.hosts[strings.ToLower(h)] = true
+	}
+}
+
+// hostsKey is the context key of the hosts allowed by a crawl.
+type hostsKey struct{}
+
+// headerKey is the context key of the http.Header a fetch
+// gets the headers of its response in.
+type headerKey struct{}
+
+// record keeps the headers of the response in the http.Header
+// of the context of the request, if any.
+func record(e *hapicli.Exchange, next hapicli.RoundTrip) (*http.Response, error) {
+	h, _ := e.HTTPRequest.Context().Value(headerKey{}).(*http.Header)
+	resp, err := next(e)
+	if h != nil && resp != nil {
+		*h = resp.Header
+	}
+	return resp, err
+}
+
+// metadataRels link to documents about the resource,
+// which are not resources of the API and are not fetched.
+var metadataRels = map[string]bool{"profile": true, "describedby": true}
+
+// target is a resource to fetch.
+type target struct {
+	url   string
+	depth int
+}
+
+// Crawl walks the API from the entry point and returns its graph.
+// A resource is fetched once per normalized URL, and once per pattern
+// of templated link: after /orders/1, /orders/2 is not fetched when an
+// /orders/{id} link was seen. The templated links are only followed
+// when their variables all are in the query.
+// The failed requests are recorded on their node, the crawl only
+// stops early when the context is done, returning the graph so far.
+func (cr *Crawler) Crawl(ctx context.Context, entry string) (*Graph, error) {
+	entry, err := normalize(entry)
+	if err != nil {
+		return nil, err
+	}
+	hosts := cr.hosts
+	if len(hosts) == 0 {
+		u, _ := url.Parse(entry)
+		hosts = map[string]bool{u.Host: true}
+	}
+	ctx = context.WithValue(ctx, hostsKey{}, hosts)
+
+	w := &walk{
+		graph:    new(Graph),
+		nodes:    make(map[string]*Node),
+		rels:     make(map[string]*relSet),
+		patterns: make(map[string]*templated),
+	}
+	w.graph.index = w.nodes
+	queue := []target{{entry, 0}}
+	w.node(entry, 0)
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		n := w.nodes[t.url]
+		u, _ := url.Parse(t.url)
+		if !hosts[u.Host] || cr.maxDepth > 0 && t.depth > cr.maxDepth || w.matchesFetched(t.url) {
+			continue
+		}
+		if cr.maxResources > 0 && w.fetched >= cr.maxResources {
+			break
+		}
+		res, err := cr.fetch(ctx, n)
+		if ctx.Err() != nil {
+			return w.done(), ctx.Err()
+		}
+		w.fetched++
+		w.matchPatterns(n)
+		if err != nil || res == nil {
+			continue
+		}
+		queue = append(queue, w.links(n, res)...)
+	}
+	return w.done(), nil
+}
+
+// fetch gets the resource of the node and fills the node
+// with what the response tells about it.
+func (cr *Crawler) fetch(ctx context.Context, n *Node) (*hal.Resource, error) {
+	get, _ := hapicli.NewRequest("GET", nil, "", "")
+	var header http.Header
+	res, err := cr.client.Send(context.WithValue(ctx, headerKey{}, &header), n.URL, get)
+	n.Fetched = true
+	if err != nil {
+		n.Err = err.Error()
+		return nil, err
+	}
+	mt, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	n.MediaType, n.Profile = mt, params["profile"]
+	if res != nil && n.Profile == "" {
+		if l, err := res.Link("profile"); err == nil {
+			n.Profile = l.Href()
+		}
+	}
+	methods := set{}
+	if allow := header.Get("Allow"); allow != "" {
+		for _, m := range strings.Split(allow, ",") {
+			methods.add(strings.ToUpper(strings.TrimSpace(m)))
+		}
+	} else {
+		methods.add("GET")
+	}
+	if res != nil {
+		for _, t := range res.Templates() {
+			if target, err := resolve(n.URL, t.Target); t.Target == "" || err == nil && target == n.URL {
+				methods.add(strings.ToUpper(t.Method))
+			}
+		}
+	}
+	n.Methods = methods.sorted()
+	return res, nil
+}
+
+// walk is the state of a crawl.
+type walk struct {
+	graph *Graph
+	nodes map[string]*Node
+	rels  map[string]*relSet
+	// The patterns of the templated links by href, matched against
+	// the URLs to fetch only one resource per pattern.
+	patterns map[string]*templated
+	fetched  int
+}
+
+// templated is the pattern of a templated link
+// and the first fetched URL it matches, if any.
+type templated struct {
+	re      *regexp.Regexp
+	fetched string
+}
+
+// relSet gathers the edges of a rel.
+type relSet struct {
+	edges []*Edge
+}
+
+// node returns the node of the URL, created if needed.
+func (w *walk) node(u string, depth int) *Node {
+	n, ok := w.nodes[u]
+	if !ok {
+		n = &Node{URL: u, Depth: depth}
+		w.nodes[u] = n
+		w.graph.Nodes = append(w.graph.Nodes, n)
+	}
+	return n
+}
+
+// links adds the edges of the links and of the embedded resources
+// of res, and returns the resources they target.
+func (w *walk) links(from *Node, res *hal.Resource) []target {
+	var next []target
+	add := func(rel string, l hal.Link, embedded bool) {
+		href, err := resolve(from.URL, l.Href())
+		if err != nil {
+			return
+		}
+		e := &Edge{From: from.URL, To: href, Rel: rel, Embedded: embedded, Deprecation: l.Deprecation(),
+			Type: l.Type(), Profile: l.Profile(), Title: l.Title()}
+		if l.Templated() {
+			e.Variables = variables(l.Href())
+			w.addPattern(href)
+		}
+		w.graph.Edges = append(w.graph.Edges, e)
+		if w.rels[rel] == nil {
+			w.rels[rel] = new(relSet)
+		}
+		w.rels[rel].edges = append(w.rels[rel].edges, e)
+
+		to := href
+		if metadataRels[rel] {
+			w.node(href, from.Depth+1)
+			return
+		}
+		if l.Templated() {
+			// Only the links whose variables all are in the query are
+			// fetched, their expansion without variables being safe.
+			if !queryOnly(l.Href()) {
+				w.node(href, from.Depth+1)
+				return
+			}
+			if to, err = normalize(expand(href)); err != nil {
+				return
+			}
+		}
+		if _, ok := w.nodes[to]; !ok {
+			next = append(next, target{to, from.Depth + 1})
+		}
+		w.node(to, from.Depth+1)
+	}
+
+	links := res.AllLinks()
+	for _, rel := range sortedKeys(links) {
+		if rel == "curies" || rel == hal.SELF.Name() {
+			continue
+		}
+		for _, l := range links[rel] {
+			add(rel, l, false)
+		}
+	}
+	ers, _ := res.AllEmbeddedResources()
+	rels := make([]string, 0, len(ers))
+	for rel := range ers {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	for _, rel := range rels {
+		for _, er := range ers[rel] {
+			if self, err := er.Link(hal.SELF.Name()); err == nil {
+				add(rel, *self, true)
+			}
+		}
+	}
+	return next
+}
+
+// addPattern adds the pattern of the templated href, matched against
+// the resources already fetched.
+func (w *walk) addPattern(href string) {
+	if _, ok := w.patterns[href]; ok {
+		return
+	}
+	p := &templated{re: pattern(href)}
+	w.patterns[href] = p
+	for _, n := range w.graph.Nodes {
+		if n.Fetched && p.re.MatchString(n.URL) {
+			p.fetched = n.URL
+			break
+		}
+	}
+}
+
+// matchPatterns records the fetched node on the patterns it matches.
+func (w *walk) matchPatterns(n *Node) {
+	for _, p := range w.patterns {
+		if p.fetched == "" && p.re.MatchString(n.URL) {
+			p.fetched = n.URL
+		}
+	}
+}
+
+// matchesFetched tells if the URL matches the pattern of a templated
+// link whose target was already fetched with an other URL.
+func (w *walk) matchesFetched(u string) bool {
+	for _, p := range w.patterns {
+		if p.fetched != "" && p.fetched != u && p.re.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// done returns the graph with the summary of the rels.
+func (w *walk) done() *Graph {
+	w.graph.Rels = nil
+	names := make([]string, 0, len(w.rels))
+	for name := range w.rels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := &Rel{Name: name}
+		mts, profiles, methods := set{}, set{}, set{}
+		for _, e := range w.rels[name].edges {
+			r.Count++
+			r.Deprecated = r.Deprecated || e.Deprecation != ""
+			mts.add(e.Type)
+			profiles.add(e.Profile)
+			to := w.nodes[targetURL(e)]
+			if to == nil || !to.Fetched || to.Err != "" {
+				continue
+			}
+			mts.add(to.MediaType)
+			profiles.add(to.Profile)
+			for _, m := range to.Methods {
+				methods.add(m)
+			}
+		}
+		r.MediaTypes, r.Profiles, r.Methods = mts.sorted(), profiles.sorted(), methods.sorted()
+		w.graph.Rels = append(w.graph.Rels, r)
+	}
+	return w.graph
+}
+
+type set map[string]bool
+
+func (s set) add(v string) {
+	if v != "" {
+		s[v] = true
+	}
+}
+
+func (s set) sorted() []string {
+	out := make([]string, 0, len(s))
+	for v := range s {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedKeys(m map[string][]hal.Link) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalize returns the normalized form of an absolute URL: lower case
+// scheme and host, no default port, no fragment, a clean path and
+// sorted query parameters.
+func normalize(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if u.Scheme == "http" && strings.HasSuffix(u.Host, ":80") || u.Scheme == "https" && strings.HasSuffix(u.Host, ":443") {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	} else if clean := path.Clean(u.Path); clean != u.Path {
+		if strings.HasSuffix(u.Path, "/") && clean != "/" {
+			clean += "/"
+		}
+		u.Path = clean
+	}
+	u.RawQuery = u.Query().Encode()
+	return u.String(), nil
+}
+
+// targetURL returns the URL of the node targeted by the edge: the
+// expansion of a templated link which was followed, or else its href.
+func targetURL(e *Edge) string {
+	if e.Variables != nil && queryOnly(e.To) {
+		if u, err := normalize(expand(e.To)); err == nil {
+			return u
+		}
+	}
+	return e.To
+}
+
+// expressions matches the expressions of a URI template.
+var expressions = regexp.MustCompile(`\{([^}]*)\}`)
+
+// resolve returns the absolute href of a link, its expressions kept
+// as they are when it is templated.
+func resolve(base, href string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	// The braces are kept out of the parsing and put back.
+	var exps []string
+	masked := expressions.ReplaceAllStringFunc(href, func(e string) string {
+		exps = append(exps, e)
+		return "TEMPLATEEXPRESSION"
+	})
+	u, err := url.Parse(masked)
+	if err != nil {
+		return "", err
+	}
+	abs := b.ResolveReference(u).String()
+	if len(exps) == 0 {
+		return normalize(abs)
+	}
+	for _, e := range exps {
+		abs = strings.Replace(abs, "TEMPLATEEXPRESSION", e, 1)
+	}
+	return abs, nil
+}
+
+// variables returns the names of the variables of a URI template.
+func variables(tpl string) []string {
+	vars := []string{}
+	for _, m := range expressions.FindAllStringSubmatch(tpl, -1) {
+		exp := strings.TrimLeft(m[1], "+#./;?&")
+		for _, name := range strings.Split(exp, ",") {
+			name = strings.TrimRight(name, "*")
+			if i := strings.Index(name, ":"); i >= 0 {
+				name = name[:i]
+			}
+			vars = append(vars, name)
+		}
+	}
+	return vars
+}
+
+// queryOnly tells if all the expressions of the template are
+// query or fragment expressions, which vanish when not defined.
+func queryOnly(tpl string) bool {
+	for _, m := range expressions.FindAllStringSubmatch(tpl, -1) {
+		if m[1] == "" || !strings.ContainsRune("?&#", rune(m[1][0])) {
+			return false
+		}
+	}
+	return true
+}
+
+// expand expands the template without variables.
+func expand(tpl string) string {
+	return expressions.ReplaceAllString(tpl, "")
+}
+
+// pattern returns the regexp matching the URLs of a template,
+// each path expression matching a path segment, the query ignored.
+func pattern(tpl string) *regexp.Regexp {
+	tpl = tpl[:queryStart(tpl)]
+	var re []string
+	last := 0
+	for _, loc := range expressions.FindAllStringIndex(tpl, -1) {
+		re = append(re, regexp.QuoteMeta(tpl[last:loc[0]]), `[^/?#]*`)
+		last = loc[1]
+	}
+	re = append(re, regexp.QuoteMeta(tpl[last:]))
+	return regexp.MustCompile("^" + strings.Join(re, "") + `([?#].*)?$`)
+}
+
+// queryStart returns the index where the query or the fragment of a
+// template starts, literally or with an expression, or its length.
+func queryStart(tpl string) int {
+	inExp := false
+	for i := 0; i < len(tpl); i++ {
+		switch c := tpl[i]; {
+		case c == '{':
+			if i+1 < len(tpl) && strings.IndexByte("?&#", tpl[i+1]) >= 0 {
+				return i
+			}
+			inExp = true
+		case c == '}':
+			inExp = false
+		case !inExp && (c == '?' || c == '#'):
+			return i
+		}
+	}
+	return len(tpl)
+}
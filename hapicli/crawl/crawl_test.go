@@ -0,0 +1,297 @@
+package crawl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newAPIServer serves a small API and counts the requests of each URL.
+func newAPIServer() (*httptest.Server, map[string]int, *sync.Mutex) {
+	docs := map[string]string{
+		"/": `{"_links":{"self":{"href":"/"},"ex:orders":{"href":"/orders{?page}","templated":true},` +
+			`"ex:order":{"href":"/orders/{id}","templated":true},"ex:customers":{"href":"customers"},` +
+			`"ex:partner":{"href":"http://partner.example/api"},"ex:legacy":{"href":"/v1","deprecation":"/docs/v1"},` +
+			`"curies":[{"name":"ex","href":"/rels/{rel}","templated":true}]}}`,
+		"/orders": `{"_links":{"self":{"href":"/orders"},"next":{"href":"/orders?page=2"}},` +
+			`"_embedded":{"items":[{"_links":{"self":{"href":"/orders/1"}}},{"_links":{"self":{"href":"/orders/2"}}}]}}`,
+		"/orders?page=2": `{"_links":{"self":{"href":"/orders?page=2"}}}`,
+		"/orders/1":      `{"_links":{"self":{"href":"/orders/1"},"ex:customer":{"href":"/customers/1","type":"application/hal+json"}}}`,
+		"/orders/2":      `{"_links":{"self":{"href":"/orders/2"},"ex:customer":{"href":"/customers/1"}}}`,
+		"/customers":     `{"_links":{"self":{"href":"/customers/"},"item":{"href":"/customers/./1#top"}}}`,
+		"/customers/1": `{"_links":{"self":{"href":"/customers/1"},"profile":{"href":"/profiles/customer"}},` +
+			`"_templates":{"default":{"method":"patch"},"remove":{"method":"DELETE","target":"/customers/1"},"order":{"method":"POST","target":"/orders"}}}`,
+	}
+	allow := map[string]string{"/orders": "GET, POST", "/orders/1": "GET,PUT,delete"}
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[r.URL.RequestURI()]++
+		mu.Unlock()
+		doc, ok := docs[r.URL.RequestURI()]
+		if !ok {
+			http.Error(w, "gone", http.StatusGone)
+			return
+		}
+		ct := "application/hal+json"
+		if r.URL.Path == "/" {
+			ct += `; profile="/profiles/root"`
+		}
+		w.Header().Set("Content-Type", ct)
+		if a, ok := allow[r.URL.Path]; ok {
+			w.Header().Set("Allow", a)
+		}
+		fmt.Fprint(w, doc)
+	}))
+	return srv, counts, &mu
+}
+
+func TestCrawl(t *testing.T) {
+	srv, counts, _ := newAPIServer()
+	defer srv.Close()
+
+	g, err := NewCrawler(nil).Crawl(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	expectedCounts := map[string]int{"/": 1, "/orders": 1, "/orders/1": 1, "/customers": 1, "/customers/1": 1, "/v1": 1}
+	if !reflect.DeepEqual(counts, expectedCounts) {
+		t.Error("expected the requests", expectedCounts, "got", counts)
+	}
+
+	data := []struct {
+		Title      string
+		URL        string
+		OutFetched bool
+		OutDepth   int
+		OutMethods []string
+		OutProfile string
+		OutErr     bool
+	}{
+		{"A", srv.URL + "/", true, 0, []string{"GET"}, "/profiles/root", false},
+		{"B", srv.URL + "/orders", true, 1, []string{"GET", "POST"}, "", false},
+		{"C", srv.URL + "/orders/{id}", false, 1, nil, "", false},
+		{"D", srv.URL + "/orders/1", true, 2, []string{"DELETE", "GET", "PUT"}, "", false},
+		{"E", srv.URL + "/orders/2", false, 2, nil, "", false},
+		{"F", srv.URL + "/customers/1", true, 2, []string{"DELETE", "GET", "PATCH"}, "/profiles/customer", false},
+		{"G", "http://partner.example/api", false, 1, nil, "", false},
+		{"H", srv.URL + "/v1", true, 1, nil, "", true},
+	}
+	for _, v := range data {
+		n := g.Node(v.URL)
+		if n == nil {
+			t.Error("for test", v.Title, "expected a node for", v.URL)
+			continue
+		}
+		if n.Fetched != v.OutFetched || n.Depth != v.OutDepth || (n.Err != "") != v.OutErr {
+			t.Error("for test", v.Title, "expected fetched", v.OutFetched, "depth", v.OutDepth, "error", v.OutErr, "got", n)
+		}
+		if !reflect.DeepEqual(n.Methods, v.OutMethods) || n.Profile != v.OutProfile {
+			t.Error("for test", v.Title, "expected", v.OutMethods, v.OutProfile, "got", n.Methods, n.Profile)
+		}
+	}
+
+	rels := []struct {
+		Title         string
+		Name          string
+		OutCount      int
+		OutMediaTypes []string
+		OutMethods    []string
+		OutProfiles   []string
+	}{
+		{"A", "ex:customer", 1, []string{"application/hal+json"}, []string{"DELETE", "GET", "PATCH"}, []string{"/profiles/customer"}},
+		{"B", "ex:orders", 1, []string{"application/hal+json"}, []string{"GET", "POST"}, []string{}},
+		{"C", "ex:order", 1, []string{}, []string{}, []string{}},
+		{"D", "items", 2, []string{"application/hal+json"}, []string{"DELETE", "GET", "PUT"}, []string{}},
+		{"E", "ex:legacy", 1, []string{}, []string{}, []string{}},
+	}
+	for _, v := range rels {
+		r := g.Rel(v.Name)
+		if r == nil {
+			t.Error("for test", v.Title, "expected the rel", v.Name)
+			continue
+		}
+		if r.Count != v.OutCount || !reflect.DeepEqual(r.MediaTypes, v.OutMediaTypes) ||
+			!reflect.DeepEqual(r.Methods, v.OutMethods) || !reflect.DeepEqual(r.Profiles, v.OutProfiles) {
+			t.Error("for test", v.Title, "expected", v.OutCount, v.OutMediaTypes, v.OutMethods, v.OutProfiles, "got", r)
+		}
+	}
+	if r := g.Rel("ex:legacy"); r == nil || !r.Deprecated {
+		t.Error("expected ex:legacy to be deprecated got", r)
+	}
+	if r := g.Rel("curies"); r != nil {
+		t.Error("expected no curies rel got", r)
+	}
+	for _, e := range g.Edges {
+		if e.Rel == "ex:order" && !reflect.DeepEqual(e.Variables, []string{"id"}) {
+			t.Error("expected the variables of ex:order got", e.Variables)
+		}
+	}
+}
+
+func TestCrawlLimits(t *testing.T) {
+	data := []struct {
+		Title        string
+		MaxDepth     int
+		MaxResources int
+		Hosts        []string
+		OutFetched   int
+	}{
+		{"A", 1, 0, nil, 4},
+		{"B", 0, 2, nil, 2},
+		{"C", 0, 0, []string{"partner.example"}, 0},
+	}
+	for _, v := range data {
+		srv, counts, _ := newAPIServer()
+		c := NewCrawler(nil)
+		c.SetMaxDepth(v.MaxDepth)
+		c.SetMaxResources(v.MaxResources)
+		if v.Hosts != nil {
+			c.SetAllowedHosts(v.Hosts...)
+		}
+		g, err := c.Crawl(context.Background(), srv.URL)
+		srv.Close()
+		if err != nil {
+			t.Error("for test", v.Title, "expected no error got", err)
+			continue
+		}
+		fetched := 0
+		for _, n := range g.Nodes {
+			if n.Fetched {
+				fetched++
+			}
+		}
+		if fetched != v.OutFetched {
+			t.Error("for test", v.Title, "expected", v.OutFetched, "resources fetched got", fetched, counts)
+		}
+	}
+}
+
+func TestCrawlConcurrent(t *testing.T) {
+	srv, _, _ := newAPIServer()
+	defer srv.Close()
+	c := NewCrawler(nil)
+
+	var wg sync.WaitGroup
+	graphs := make([]*Graph, 4)
+	for i := range graphs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			graphs[i], _ = c.Crawl(context.Background(), srv.URL)
+		}(i)
+	}
+	wg.Wait()
+	for i, g := range graphs {
+		if g == nil {
+			t.Fatal("for crawl", i, "expected a graph")
+		}
+		for url, methods := range map[string][]string{"/orders": {"GET", "POST"}, "/orders/1": {"DELETE", "GET", "PUT"}, "/customers/1": {"DELETE", "GET", "PATCH"}} {
+			if n := g.Node(srv.URL + url); n == nil || !reflect.DeepEqual(n.Methods, methods) {
+				t.Error("for crawl", i, "expected", url, "to allow", methods, "got", n)
+			}
+		}
+	}
+}
+
+func TestCrawlRedirect(t *testing.T) {
+	hits := 0
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/hal+json")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/away":
+			http.Redirect(w, r, other.URL+"/", http.StatusFound)
+		case "/moved":
+			http.Redirect(w, r, "/orders", http.StatusMovedPermanently)
+		default:
+			w.Header().Set("Content-Type", "application/hal+json")
+			fmt.Fprint(w, `{"_links":{"ex:away":{"href":"/away"},"ex:moved":{"href":"/moved"}}}`)
+		}
+	}))
+	defer srv.Close()
+
+	g, err := NewCrawler(nil).Crawl(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if hits != 0 {
+		t.Error("expected the other host not to be requested got", hits, "hits")
+	}
+	if n := g.Node(srv.URL + "/away"); n == nil || !strings.Contains(n.Err, errorRedirectHost.Error()) {
+		t.Error("expected the redirect to be refused got", n)
+	}
+	if n := g.Node(srv.URL + "/moved"); n == nil || n.Err != "" {
+		t.Error("expected the redirect on the host to be followed got", n)
+	}
+}
+
+func TestCrawlRateLimit(t *testing.T) {
+	srv, _, _ := newAPIServer()
+	defer srv.Close()
+	c := NewCrawler(nil)
+	c.SetRateLimit(50)
+	start := time.Now()
+	if _, err := c.Crawl(context.Background(), srv.URL); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	// 6 requests, 20ms apart
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Error("expected the requests to be spaced got", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	g, err := c.Crawl(ctx, srv.URL)
+	if err != context.DeadlineExceeded || g == nil {
+		t.Error("expected a partial graph and", context.DeadlineExceeded, "got", g, err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	data := []struct {
+		Title string
+		In    string
+		Out   string
+	}{
+		{"A", "HTTP://Example.COM:80", "http://example.com/"},
+		{"B", "https://example.com:443/a/./b/../c/", "https://example.com/a/c/"},
+		{"C", "http://example.com/a?b=2&a=1#top", "http://example.com/a?a=1&b=2"},
+		{"D", "http://example.com:8080/a", "http://example.com:8080/a"},
+	}
+	for _, v := range data {
+		if out, _ := normalize(v.In); out != v.Out {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+}
+
+func TestGraphNode(t *testing.T) {
+	g := &Graph{Nodes: []*Node{{URL: "http://example.com/"}}}
+	if n := g.Node("http://example.com/"); n == nil || n != g.Nodes[0] {
+		t.Error("expected the node of the entry point got", n)
+	}
+	g.Nodes = append(g.Nodes, &Node{URL: "http://example.com/orders?id=1"})
+	e := &Edge{To: "http://example.com/orders{?id}", Variables: []string{"id"}}
+	if n := g.Target(e); n != nil {
+		t.Error("expected no node for the expansion got", n)
+	}
+	g.Nodes = append(g.Nodes, &Node{URL: "http://example.com/orders"})
+	if n := g.Target(e); n == nil || n != g.Nodes[2] {
+		t.Error("expected the node added after the first lookup got", n)
+	}
+	if n := g.Node("http://example.com/missing"); n != nil {
+		t.Error("expected no node got", n)
+	}
+}
@@ -0,0 +1,208 @@
+package crawl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// WriteDOT writes the graph in the DOT language of Graphviz.
+// The resources sharing a profile are clustered, the resources
+// which were not fetched are dashed and the failed ones red.
+// The deprecated links are dashed and gray, the embedded resources
+// are linked by diamond arrows and the templated links are labelled
+// with their variables.
+//
+//	g.WriteDOT(f)
+//	// dot -Tsvg api.dot > api.svg
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	ids := g.ids()
+	fmt.Fprintln(bw, "digraph api {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+	for i, c := range g.clusters() {
+		indent := "  "
+		if c.profile != "" {
+			fmt.Fprintf(bw, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(bw, "    label=%s;\n", dotQuote(c.profile))
+			indent = "    "
+		}
+		for _, n := range c.nodes {
+			var attrs []string
+			attrs = append(attrs, "label="+dotQuote(g.label(n)))
+			switch {
+			case n.Err != "":
+				attrs = append(attrs, "color=red", "tooltip="+dotQuote(n.Err))
+			case !n.Fetched:
+				attrs = append(attrs, "style=dashed")
+			}
+			fmt.Fprintf(bw, "%s%s [%s];\n", indent, ids[n.URL], strings.Join(attrs, ", "))
+		}
+		if c.profile != "" {
+			fmt.Fprintln(bw, "  }")
+		}
+	}
+	for _, e := range g.edges() {
+		to := g.Target(e)
+		if to == nil {
+			continue
+		}
+		attrs := []string{"label=" + dotQuote(edgeLabel(e))}
+		if e.Deprecation != "" {
+			attrs = append(attrs, "style=dashed", "color=gray", "tooltip="+dotQuote("deprecated: "+e.Deprecation))
+		}
+		if e.Embedded {
+			attrs = append(attrs, "arrowhead=diamond")
+		}
+		fmt.Fprintf(bw, "  %s -> %s [%s];\n", ids[e.From], ids[to.URL], strings.Join(attrs, ", "))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, styled
+// as WriteDOT does: a subgraph per profile, the deprecated links
+// dotted and gray, the embedded resources linked by thick arrows
+// and the templated links labelled with their variables.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	ids := g.ids()
+	fmt.Fprintln(bw, "flowchart LR")
+	var unfetched, failed []string
+	for i, c := range g.clusters() {
+		indent := "  "
+		if c.profile != "" {
+			fmt.Fprintf(bw, "  subgraph profile%d [%s]\n", i, mermaidQuote(c.profile))
+			indent = "    "
+		}
+		for _, n := range c.nodes {
+			fmt.Fprintf(bw, "%s%s[%s]\n", indent, ids[n.URL], mermaidQuote(g.label(n)))
+			switch {
+			case n.Err != "":
+				failed = append(failed, ids[n.URL])
+			case !n.Fetched:
+				unfetched = append(unfetched, ids[n.URL])
+			}
+		}
+		if c.profile != "" {
+			fmt.Fprintln(bw, "  end")
+		}
+	}
+	var deprecated []string
+	i := 0
+	for _, e := range g.edges() {
+		to := g.Target(e)
+		if to == nil {
+			continue
+		}
+		arrow := "-->"
+		if e.Embedded {
+			arrow = "==>"
+		}
+		if e.Deprecation != "" {
+			arrow = "-.->"
+			deprecated = append(deprecated, fmt.Sprint(i))
+		}
+		fmt.Fprintf(bw, "  %s %s|%s| %s\n", ids[e.From], arrow, mermaidQuote(edgeLabel(e)), ids[to.URL])
+		i++
+	}
+	if len(unfetched) > 0 {
+		fmt.Fprintln(bw, "  classDef unfetched stroke-dasharray: 5 5")
+		fmt.Fprintf(bw, "  class %s unfetched\n", strings.Join(unfetched, ","))
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(bw, "  classDef failed stroke:red")
+		fmt.Fprintf(bw, "  class %s failed\n", strings.Join(failed, ","))
+	}
+	if len(deprecated) > 0 {
+		fmt.Fprintf(bw, "  linkStyle %s stroke:gray\n", strings.Join(deprecated, ","))
+	}
+	return bw.Flush()
+}
+
+// cluster is the nodes sharing a profile.
+type cluster struct {
+	profile string
+	nodes   []*Node
+}
+
+// clusters groups the nodes by profile, the nodes without a
+// profile first then the profiles in alphabetical order.
+func (g *Graph) clusters() []cluster {
+	byProfile := make(map[string][]*Node)
+	for _, n := range g.Nodes {
+		byProfile[n.Profile] = append(byProfile[n.Profile], n)
+	}
+	profiles := make([]string, 0, len(byProfile))
+	for p := range byProfile {
+		profiles = append(profiles, p)
+	}
+	sort.Strings(profiles)
+	cs := make([]cluster, len(profiles))
+	for i, p := range profiles {
+		cs[i] = cluster{p, byProfile[p]}
+	}
+	return cs
+}
+
+// ids returns the identifiers of the nodes: n0, n1...
+func (g *Graph) ids() map[string]string {
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.URL] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
+
+// edges returns the edges without the duplicates,
+// the same rel linking the same resources.
+func (g *Graph) edges() []*Edge {
+	seen := make(map[string]bool)
+	var out []*Edge
+	for _, e := range g.Edges {
+		k := e.From + " " + e.Rel + " " + e.To
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// label returns the label of a node: its URL, without the scheme
+// and the host when they are the ones of the entry point.
+func (g *Graph) label(n *Node) string {
+	if len(g.Nodes) == 0 {
+		return n.URL
+	}
+	entry, err := url.Parse(g.Nodes[0].URL)
+	if err != nil {
+		return n.URL
+	}
+	prefix := entry.Scheme + "://" + entry.Host
+	if strings.HasPrefix(n.URL, prefix+"/") {
+		return n.URL[len(prefix):]
+	}
+	return n.URL
+}
+
+// edgeLabel returns the rel of the edge, with the variables
+// of a templated link.
+func edgeLabel(e *Edge) string {
+	if len(e.Variables) == 0 {
+		return e.Rel
+	}
+	return e.Rel + " {" + strings.Join(e.Variables, ",") + "}"
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, "#quot;", -1) + `"`
+}
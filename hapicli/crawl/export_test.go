@@ -0,0 +1,51 @@
+package crawl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+// newGraph returns a graph as drawn by a crawl.
+func newGraph() *Graph {
+	root := &Node{URL: "http://api.example/", Fetched: true, MediaType: "application/hal+json", Methods: []string{"GET"}}
+	orders := &Node{URL: "http://api.example/orders", Depth: 1, Fetched: true, Profile: "/profiles/orders", Methods: []string{"GET", "POST"}}
+	order := &Node{URL: "http://api.example/orders/{id}", Depth: 1}
+	order1 := &Node{URL: "http://api.example/orders/1", Depth: 2, Fetched: true, Profile: "/profiles/orders", Methods: []string{"GET"}}
+	legacy := &Node{URL: "http://api.example/v1", Depth: 1, Fetched: true, Err: `410 "Gone"`}
+	partner := &Node{URL: "http://partner.example/api", Depth: 1}
+	return &Graph{
+		Nodes: []*Node{root, orders, order, order1, legacy, partner},
+		Edges: []*Edge{
+			{From: root.URL, To: "http://api.example/orders{?page}", Rel: "ex:orders", Variables: []string{"page"}},
+			{From: root.URL, To: order.URL, Rel: "ex:order", Variables: []string{"id"}},
+			{From: root.URL, To: legacy.URL, Rel: "ex:legacy", Deprecation: "/docs/v1"},
+			{From: root.URL, To: partner.URL, Rel: "ex:partner"},
+			{From: orders.URL, To: order1.URL, Rel: "items", Embedded: true},
+			{From: orders.URL, To: order1.URL, Rel: "items", Embedded: true},
+			{From: order1.URL, To: "http://api.example/missing", Rel: "ex:lost"},
+		},
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newGraph().WriteDOT(&buf); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	expected, _ := ioutil.ReadFile("testdata/api.dot")
+	if buf.String() != string(expected) {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newGraph().WriteMermaid(&buf); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	expected, _ := ioutil.ReadFile("testdata/api.mmd")
+	if buf.String() != string(expected) {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,89 @@
+package crawl
+
+// Graph is the map of an API drawn by a Crawler: the resources
+// reached, the links between them and a summary of each rel.
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+	Rels  []*Rel
+
+	// The nodes by URL, rebuilt when the nodes are added.
+	index map[string]*Node
+}
+
+// Node is a resource of the API, identified by its normalized URL,
+// or by its href pattern for a templated link which was not fetched.
+type Node struct {
+	URL string
+	// The number of links followed from the entry point.
+	Depth int
+	// False for a resource out of the crawl: a templated link,
+	// an other host, or beyond the maximum depth.
+	Fetched bool
+	// The media type of the response and its profile, either
+	// the profile parameter of the Content-Type or the profile link.
+	MediaType string
+	Profile   string
+	// The methods allowed on the resource: those of the Allow header of
+	// the response, GET when it has none, and those of the HAL-FORMS
+	// templates of the resource targeting it. No OPTIONS request is sent.
+	Methods []string
+	// The error of the request, if any.
+	Err string
+}
+
+// Edge is a link from a resource to an other.
+type Edge struct {
+	From string
+	To   string
+	Rel  string
+	// The link is an embedded resource.
+	Embedded bool
+	// The variables of a templated link, nil when it is not templated.
+	Variables []string
+	// The deprecation URL of the link, if any.
+	Deprecation string
+	// The hints of the link.
+	Type    string
+	Profile string
+	Title   string
+}
+
+// Rel summarizes the links of a relation type:
+// what was observed on the resources they target.
+type Rel struct {
+	Name       string
+	Count      int
+	MediaTypes []string
+	Profiles   []string
+	Methods    []string
+	Deprecated bool
+}
+
+// Node returns the node of the URL, nil if there is none.
+func (g *Graph) Node(url string) *Node {
+	if len(g.index) != len(g.Nodes) {
+		g.index = make(map[string]*Node, len(g.Nodes))
+		for _, n := range g.Nodes {
+			if _, ok := g.index[n.URL]; !ok {
+				g.index[n.URL] = n
+			}
+		}
+	}
+	return g.index[url]
+}
+
+// Target returns the node targeted by the edge, nil if there is none.
+func (g *Graph) Target(e *Edge) *Node {
+	return g.Node(targetURL(e))
+}
+
+// Rel returns the summary of the rel, nil if there is none.
+func (g *Graph) Rel(name string) *Rel {
+	for _, r := range g.Rels {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
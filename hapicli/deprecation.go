@@ -0,0 +1,94 @@
+package hapicli
+
+import (
+	"net/http"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// Deprecation is the notification of the traversal of a deprecated link,
+// or of a response announcing the deprecation of its resource with the
+// Deprecation and Sunset headers.
+// see https://tools.ietf.org/html/draft-kelly-json-hal-07#section-5.4
+// see https://tools.ietf.org/html/rfc8594
+type Deprecation struct {
+	// The relation type of the followed link, if any.
+	Rel string
+	// The href of the link or the URL of the request.
+	Href string
+	// The deprecation property of the link: a URL that
+	// provides further information about the deprecation.
+	Info string
+	// The Deprecation and Sunset headers of the response.
+	Date   string
+	Sunset string
+}
+
+// DeprecationHandler is notified of each deprecation,
+// once per link or URL.
+type DeprecationHandler func(d Deprecation)
+
+// SetDeprecationHandler is setting the handler notified of the
+// deprecations, the default handler logs a warning with the logger.
+func (c *Client) SetDeprecationHandler(h DeprecationHandler) {
+	c.deprecationHandler = h
+}
+
+// traverse notifies the deprecation of the link followed for rel.
+func (c *Client) traverse(rel string, l *hal.Link) {
+	if l.Deprecation() == "" {
+		return
+	}
+	c.deprecate(Deprecation{Rel: rel, Href: l.Href(), Info: l.Deprecation()})
+}
+
+// deprecatedResponse notifies the deprecation
+// announced by the headers of the response.
+func (c *Client) deprecatedResponse(u string, h http.Header) {
+	d := Deprecation{Href: u, Date: h.Get("Deprecation"), Sunset: h.Get("Sunset")}
+	if d.Date == "" && d.Sunset == "" {
+		return
+	}
+	c.deprecate(d)
+}
+
+// deprecate calls the handler the first time d is seen.
+func (c *Client) deprecate(d Deprecation) {
+	c.mu.Lock()
+	if c.deprecations == nil {
+		c.deprecations = make(map[Deprecation]bool)
+	}
+	seen := c.deprecations[d]
+	c.deprecations[d] = true
+	c.mu.Unlock()
+	if seen {
+		return
+	}
+	if c.deprecationHandler != nil {
+		c.deprecationHandler(d)
+		return
+	}
+	c.logDeprecation(d)
+}
+
+// logDeprecation is the default DeprecationHandler.
+func (c *Client) logDeprecation(d Deprecation) {
+	args := []interface{}{"href", d.Href}
+	if d.Rel != "" {
+		args = append(args, "rel", d.Rel)
+	}
+	if d.Info != "" {
+		args = append(args, "deprecation", d.Info)
+	}
+	if d.Date != "" {
+		args = append(args, "date", d.Date)
+	}
+	if d.Sunset != "" {
+		args = append(args, "sunset", d.Sunset)
+	}
+	msg := "deprecated link traversed"
+	if d.Info == "" {
+		msg = "deprecated resource requested"
+	}
+	c.logger.Warn(msg, args...)
+}
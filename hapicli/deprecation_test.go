@@ -0,0 +1,98 @@
+package hapicli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// newDeprecatedServer serves an entry point with a deprecated link
+// to /old, and /sunset whose responses announce its deprecation.
+func newDeprecatedServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/hal+json")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `{"_links":{
+				"ex:old":{"href":"/old","deprecation":"https://example.com/deprecations/old"},
+				"ex:sunset":{"href":"/sunset"}}}`)
+		case "/sunset":
+			w.Header().Set("Deprecation", "@1688169599")
+			w.Header().Set("Sunset", "Wed, 11 Nov 2026 23:59:59 GMT")
+			fmt.Fprint(w, `{}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+}
+
+// recordLogger keeps the messages and args logged,
+// the warnings in msgs and the debug ones in debugs.
+type recordLogger struct {
+	msgs   []string
+	debugs [][]interface{}
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) {
+	l.debugs = append(l.debugs, append([]interface{}{msg}, args...))
+}
+
+func (l *recordLogger) Warn(msg string, args ...interface{}) {
+	l.msgs = append(l.msgs, msg+formatArgs(args))
+}
+
+func TestDeprecationHandler(t *testing.T) {
+	srv := newDeprecatedServer()
+	defer srv.Close()
+	old, _ := hal.NewCustomRel("ex:old")
+	sunset, _ := hal.NewCustomRel("ex:sunset")
+
+	var ds []Deprecation
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	c.SetDeprecationHandler(func(d Deprecation) {
+		ds = append(ds, d)
+	})
+	for i := 0; i < 2; i++ {
+		for _, rel := range []hal.Rel{old, sunset} {
+			if _, err := c.SendFollow(context.Background(), nil, NewFollow(rel, nil)); err != nil {
+				t.Fatal("expected no error got", err)
+			}
+		}
+	}
+
+	want := []Deprecation{
+		{Rel: "ex:old", Href: "/old", Info: "https://example.com/deprecations/old"},
+		{Href: srv.URL + "/sunset", Date: "@1688169599", Sunset: "Wed, 11 Nov 2026 23:59:59 GMT"},
+	}
+	if len(ds) != len(want) {
+		t.Fatal("expected", want, "got", ds)
+	}
+	for i := range want {
+		if ds[i] != want[i] {
+			t.Error("expected", want[i], "got", ds[i])
+		}
+	}
+}
+
+func TestDeprecationLogger(t *testing.T) {
+	srv := newDeprecatedServer()
+	defer srv.Close()
+	old, _ := hal.NewCustomRel("ex:old")
+
+	l := &recordLogger{}
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	c.SetLogger(l)
+	c.SendFollow(context.Background(), nil, NewFollow(old, nil))
+	c.SendFollow(context.Background(), nil, NewFollow(old, nil))
+
+	want := "deprecated link traversed href=/old rel=ex:old deprecation=https://example.com/deprecations/old"
+	if len(l.msgs) != 1 || l.msgs[0] != want {
+		t.Error("expected", want, "got", l.msgs)
+	}
+}
@@ -0,0 +1,59 @@
+package hapicli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// ErrResponse is returned when the server responds with
+// a client (4xx) or server (5xx) error status code.
+type ErrResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *ErrResponse) Error() string {
+	return fmt.Sprintf("Hapicli: the server responded with the status %d", e.StatusCode)
+}
+
+// ErrPreconditionFailed is returned when a conditional request is
+// rejected with 412 Precondition Failed: the resource has been
+// modified since it was fetched.
+// Resource is the current representation of the resource, with its
+// new validators, so the change can be merged and sent again.
+type ErrPreconditionFailed struct {
+	Resource *hal.Resource
+}
+
+func (e *ErrPreconditionFailed) Error() string {
+	return "Hapicli: the resource has been modified since it was fetched"
+}
+
+// ErrInvalidRequest is returned when the profile validation is enabled
+// and the request sent on a link does not follow the ALPS descriptor of
+// the link. The request has not been sent.
+type ErrInvalidRequest struct {
+	Rel      string
+	Method   string
+	Problems []string
+}
+
+func (e *ErrInvalidRequest) Error() string {
+	return fmt.Sprintf("Hapicli: the %s request on %s does not follow its profile: %s",
+		e.Method, e.Rel, strings.Join(e.Problems, ", "))
+}
+
+// ErrInvalidTemplate is returned when the values submitted with a
+// HAL-FORMS template do not satisfy its properties.
+// The request has not been sent.
+type ErrInvalidTemplate struct {
+	Template string
+	Problems []string
+}
+
+func (e *ErrInvalidTemplate) Error() string {
+	return fmt.Sprintf("Hapicli: the values do not fill the template %s: %s",
+		e.Template, strings.Join(e.Problems, ", "))
+}
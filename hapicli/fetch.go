@@ -0,0 +1,101 @@
+package hapicli
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// The number of workers used by FetchAll when none is given.
+const defaultWorkers = 4
+
+// FetchParam are the optional params of FetchAll
+type FetchParam struct {
+	// The maximum number of requests sent at the same time.
+	Workers int
+	// Stop the batch at the first error instead of collecting it.
+	FailFast bool
+}
+
+// FetchResult is the outcome of the GET of one link.
+type FetchResult struct {
+	Resource *hal.Resource
+	Err      error
+}
+
+// FetchAll gets the resources targeted by the links concurrently.
+// The requests go through the client so they share its authentication,
+// its transport and its rate limit, and at most fp.Workers of them are
+// in flight.
+// The results are in the order of the links, each holding the resource
+// or the error of its link. With fp.FailFast the first error cancels
+// the requests not sent yet and is returned.
+func (c *Client) FetchAll(ctx context.Context, links []hal.Link, fp FetchParam) ([]FetchResult, error) {
+	workers := fp.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]FetchResult, len(links))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res, err := c.Fetch(ctx, &links[i])
+				results[i] = FetchResult{res, err}
+				if err != nil && fp.FailFast {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	for i := range links {
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, firstErr
+}
+
+// Fetch gets the resource targeted by the link.
+// It makes the client a hal.Fetcher, to get the related resources
+// which are not embedded.
+func (c *Client) Fetch(ctx context.Context, l *hal.Link) (*hal.Resource, error) {
+	get := &Request{method: "GET"}
+	c.traverse("", l)
+	return c.send(ctx, &call{url: linkURL(l, get), request: get, link: l})
+}
+
+// SelfLinks returns the self links of the resources,
+// e.g. of the items embedded in a page, to be given to FetchAll.
+// The resources without a unique self link are skipped.
+func SelfLinks(rs []*hal.Resource) []hal.Link {
+	ls := make([]hal.Link, 0, len(rs))
+	for _, r := range rs {
+		if l, err := r.Link(hal.SELF.Name()); err == nil {
+			ls = append(ls, *l)
+		}
+	}
+	return ls
+}
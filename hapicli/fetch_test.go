@@ -0,0 +1,145 @@
+package hapicli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// newItemServer serves the items /items/{id}, /items/bad answers 500.
+// It tracks the highest number of requests handled at the same time.
+func newItemServer(maxInFlight *int32) *httptest.Server {
+	var inFlight int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		id := strings.TrimPrefix(r.URL.Path, "/items/")
+		if id == "bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/hal+json")
+		fmt.Fprintf(w, `{"_links":{"self":{"href":"/items/%s"}},"id":"%s"}`, id, id)
+	}))
+}
+
+func itemLinks(t *testing.T, base string, ids ...string) []hal.Link {
+	var rs []*hal.Resource
+	for _, id := range ids {
+		r, err := hal.NewRessourcefromJson([]byte(`{"_links":{"self":{"href":"` + base + `/items/` + id + `"}}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+	return SelfLinks(rs)
+}
+
+func TestFetchAll(t *testing.T) {
+	var maxInFlight int32
+	srv := newItemServer(&maxInFlight)
+	defer srv.Close()
+
+	var ids []string
+	for i := 0; i < 20; i++ {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	ids[7] = "bad"
+	results, err := NewClient(nil).FetchAll(context.Background(), itemLinks(t, srv.URL, ids...), FetchParam{Workers: 3})
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	for i, r := range results {
+		if i == 7 {
+			if e, ok := r.Err.(*ErrResponse); !ok || e.StatusCode != 500 {
+				t.Error("expected a 500 error for item 7 got", r.Err)
+			}
+			continue
+		}
+		if r.Err != nil || r.Resource.State()["id"] != ids[i] {
+			t.Error("expected item", ids[i], "got", r.Resource, r.Err)
+		}
+	}
+	if maxInFlight > 3 {
+		t.Error("expected at most 3 requests in flight got", maxInFlight)
+	}
+}
+
+func TestFetchAllFailFast(t *testing.T) {
+	var maxInFlight int32
+	srv := newItemServer(&maxInFlight)
+	defer srv.Close()
+
+	ids := []string{"bad", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	results, err := NewClient(nil).FetchAll(context.Background(), itemLinks(t, srv.URL, ids...), FetchParam{Workers: 1, FailFast: true})
+	if e, ok := err.(*ErrResponse); !ok || e.StatusCode != 500 {
+		t.Fatal("expected the 500 error got", err)
+	}
+	if results[len(results)-1].Err == nil {
+		t.Error("expected the last item not to be fetched")
+	}
+}
+
+func TestFetchAllRateLimit(t *testing.T) {
+	var maxInFlight int32
+	srv := newItemServer(&maxInFlight)
+	defer srv.Close()
+
+	c := NewClient(nil)
+	c.SetRateLimit(100)
+	start := time.Now()
+	results, err := c.FetchAll(context.Background(), itemLinks(t, srv.URL, "1", "2", "3", "4", "5", "6"), FetchParam{Workers: 6})
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	// 6 requests, 10ms apart whatever the workers
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Error("expected the requests to be spaced got", d)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Error("expected no error got", r.Err)
+		}
+	}
+
+	c.SetRateLimit(1)
+	links := itemLinks(t, srv.URL, "7", "8")
+	if _, err := c.Fetch(context.Background(), &links[0]); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Fetch(ctx, &links[1]); err != context.DeadlineExceeded {
+		t.Error("expected", context.DeadlineExceeded, "while waiting got", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	var maxInFlight int32
+	srv := newItemServer(&maxInFlight)
+	defer srv.Close()
+
+	page, _ := hal.NewRessourcefromJson([]byte(`{"_links":{"item":{"href":"` + srv.URL + `/items/42"}}}`))
+	var f hal.Fetcher = NewClient(nil)
+	item, err := page.Related(context.Background(), "item", f)
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if item.State()["id"] != "42" {
+		t.Error("expected item 42 got", item.State())
+	}
+}
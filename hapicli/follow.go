@@ -0,0 +1,105 @@
+package hapicli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+var (
+	errorNoEntryPoint = errors.New("Hapicli: the API URL must be set to get the entry point")
+	errorNoResource   = errors.New("Hapicli: the response has no resource to follow from")
+)
+
+// Follow is a relation to follow from a resource
+// with the request to send on the link.
+type Follow struct {
+	rel     hal.Rel
+	request AbstractRequester
+}
+
+// NewFollow create a Follow
+// - param rel	hal.Rel				The relation type of the link to follow
+// - param r	AbstractRequester	The request to send (a GET when nil)
+//
+// The url variables of the request fill the link when it is templated.
+func NewFollow(rel hal.Rel, r AbstractRequester) *Follow {
+	if r == nil {
+		r = &Request{method: "GET"}
+	}
+	return &Follow{rel: rel, request: r}
+}
+
+// Rel returns the relation type of the link to follow.
+func (f *Follow) Rel() hal.Rel {
+	return f.rel
+}
+
+// Request returns the request to send on the link.
+func (f *Follow) Request() AbstractRequester {
+	return f.request
+}
+
+// EntryPoint gets the resource at the API URL.
+func (c *Client) EntryPoint(ctx context.Context) (*hal.Resource, error) {
+	if c.apiURL == "" {
+		return nil, errorNoEntryPoint
+	}
+	return c.Send(ctx, c.apiURL, &Request{method: "GET"})
+}
+
+// SendFollow follows the relations one after the other,
+// starting from the given resource or from the entry point when nil.
+// return	*hal.Resource	The resource returned by the last follow.
+// throws hal.ErrRelNotFound
+// throws *ErrInvalidRequest
+// throws *ErrResponse
+func (c *Client) SendFollow(ctx context.Context, res *hal.Resource, follows ...*Follow) (_ *hal.Resource, err error) {
+	ctx, span := c.startSpan(ctx, "hapicli.follow")
+	defer func() { span.End(err) }()
+	rels := make([]string, len(follows))
+	for i, f := range follows {
+		rels[i] = f.rel.Name()
+	}
+	span.SetAttribute(AttrRels, rels)
+
+	if res == nil {
+		if res, err = c.EntryPoint(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range follows {
+		if res == nil {
+			return nil, errorNoResource
+		}
+		if res, err = c.follow(ctx, res, f); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// follow sends the request of f on the link of res.
+func (c *Client) follow(ctx context.Context, res *hal.Resource, f *Follow) (*hal.Resource, error) {
+	l, err := res.Link(f.rel.Name())
+	if err != nil {
+		return nil, err
+	}
+	c.traverse(f.rel.Name(), l)
+	if c.validateProfiles && l.Profile() != "" {
+		if err := c.validateRequest(ctx, f.rel.Name(), l, f.request); err != nil {
+			return nil, err
+		}
+	}
+	return c.send(ctx, &call{url: linkURL(l, f.request), request: f.request, rel: f.rel.Name(), link: l})
+}
+
+// linkURL returns the href of the link,
+// expanded with the url variables of r when templated.
+func linkURL(l *hal.Link, r AbstractRequester) string {
+	if !l.Templated() {
+		return l.Href()
+	}
+	return expandURI(l.Href(), urlVariables(r.UrlVariables()))
+}
@@ -0,0 +1,45 @@
+package hapicli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestSendFollow(t *testing.T) {
+	srv := newCollectionServer(3, 2)
+	defer srv.Close()
+	orders, _ := hal.NewCustomRel("ex:orders")
+	unknown, _ := hal.NewCustomRel("ex:unknown")
+	page2, _ := NewRequest("GET", []string{"page=2"}, "", "")
+
+	data := []struct {
+		Title   string
+		Follows []*Follow
+		OutPage float64
+		OutErr  error
+	}{
+		{"A", []*Follow{NewFollow(orders, nil)}, 1, nil},
+		{"B", []*Follow{NewFollow(orders, page2)}, 2, nil},
+		{"C", []*Follow{NewFollow(orders, page2), NewFollow(hal.NEXT, nil)}, 3, nil},
+		{"D", []*Follow{NewFollow(unknown, nil)}, 0, hal.ErrRelNotFound},
+	}
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	for _, v := range data {
+		res, err := c.SendFollow(context.Background(), nil, v.Follows...)
+		if err != v.OutErr {
+			t.Error("for test", v.Title, "expected", v.OutErr, "got", err)
+		}
+		if err == nil && res.State()["page"] != v.OutPage {
+			t.Error("for test", v.Title, "expected page", v.OutPage, "got", res.State()["page"])
+		}
+	}
+}
+
+func TestEntryPoint(t *testing.T) {
+	if _, err := NewClient(nil).EntryPoint(context.Background()); err != errorNoEntryPoint {
+		t.Error("expected", errorNoEntryPoint, "got", err)
+	}
+}
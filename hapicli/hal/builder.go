@@ -0,0 +1,131 @@
+package hal
+
+// Builder builds a Resource of any shape, link after link:
+//
+//	r, err := hal.NewBuilder().
+//		Self("/orders/1").
+//		Curie("ex", "https://example.com/rels/{rel}").
+//		Prop("amount", 100).
+//		Link("ex:mandate", mandateLink).
+//		Embed("ex:customer", customer).
+//		Build()
+//
+// The first error, e.g. an empty href, is returned by Build.
+type Builder struct {
+	r   *Resource
+	err error
+}
+
+// NewBuilder create a Builder of an empty resource
+func NewBuilder() *Builder {
+	b := &Builder{r: new(Resource)}
+	b.r.init()
+	return b
+}
+
+// Self adds the self link of the resource.
+func (b *Builder) Self(href string) *Builder {
+	return b.Href(SELF.Name(), href, LinkOptionalParam{})
+}
+
+// Curie adds a CURIE: a templated link of the curies rel, whose {rel}
+// variable is replaced by the reference of the rels prefixed by name.
+func (b *Builder) Curie(name, href string) *Builder {
+	l, err := NewLink(href, LinkOptionalParam{Templated: true, Name: name})
+	if err != nil {
+		return b.fail(err)
+	}
+	b.r.AddLinks(CURIES.Name(), *l)
+	return b
+}
+
+// Href adds a link of the rel built from the href and the optional params.
+func (b *Builder) Href(rel, href string, lop LinkOptionalParam) *Builder {
+	l, err := NewLink(href, lop)
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.Link(rel, l)
+}
+
+// Link adds a link of the rel, represented by a Link Object
+// unless the rel holds several links.
+func (b *Builder) Link(rel string, l *Link) *Builder {
+	if l == nil {
+		return b.fail(ErrPropMandatory)
+	}
+	b.r.AddLink(rel, *l)
+	return b
+}
+
+// Links adds links of the rel, represented by an array of Link Objects.
+func (b *Builder) Links(rel string, ls ...*Link) *Builder {
+	out := make([]Link, len(ls))
+	for i, l := range ls {
+		if l == nil {
+			return b.fail(ErrPropMandatory)
+		}
+		out[i] = *l
+	}
+	b.r.AddLinks(rel, out...)
+	return b
+}
+
+// Prop sets a property of the state of the resource.
+func (b *Builder) Prop(name string, v interface{}) *Builder {
+	b.r.SetProperty(name, v)
+	return b
+}
+
+// Embed embeds a resource with the rel, represented by a Resource
+// Object unless the rel holds several resources.
+func (b *Builder) Embed(rel string, child *Resource) *Builder {
+	b.r.AddEmbeddedResource(rel, child)
+	return b
+}
+
+// EmbedAll embeds resources with the rel,
+// represented by an array of Resource Objects.
+func (b *Builder) EmbedAll(rel string, children ...*Resource) *Builder {
+	b.r.AddEmbeddedResources(rel, children...)
+	return b
+}
+
+// Template adds the HAL-FORMS template of the given key,
+// DefaultTemplate for the single template of the resource.
+func (b *Builder) Template(key string, t *Template) *Builder {
+	if t == nil {
+		return b.fail(ErrPropMandatory)
+	}
+	if t.Method == "" {
+		return b.fail(errorTemplateNoMethod)
+	}
+	b.r.AddTemplate(key, t)
+	return b
+}
+
+// Build returns the resource, or the first error of the builder.
+func (b *Builder) Build() (*Resource, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.r, nil
+}
+
+// MustBuild returns the resource and panics on error,
+// e.g. to declare the resources of the tests.
+func (b *Builder) MustBuild() *Resource {
+	r, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// fail keeps the first error of the builder.
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
@@ -0,0 +1,52 @@
+package hal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	mandate, _ := NewLink("/mandates/1", LinkOptionalParam{Title: "The mandate"})
+	child := NewBuilder().Self("/customers/1").Prop("name", "bob").MustBuild()
+
+	data := []struct {
+		title  string
+		in     *Builder
+		outErr error
+		out    string
+	}{
+		{"A", NewBuilder().Self("/orders/1"), nil, `{"_links":{"self":{"href":"/orders/1"}}}`},
+		{"B", NewBuilder().Prop("amount", 100), nil, `{"amount":100}`},
+		{"C", NewBuilder(), nil, `{}`},
+		{
+			"D",
+			NewBuilder().Self("/orders/1").Curie("ex", "/rels/{rel}").Link("ex:mandate", mandate).
+				Href("ex:search", "/orders{?q}", LinkOptionalParam{Templated: true}).Embed("ex:customer", child).Prop("amount", 100),
+			nil,
+			`{"_links":{"curies":[{"href":"/rels/{rel}","templated":true,"name":"ex"}],` +
+				`"ex:mandate":{"href":"/mandates/1","title":"The mandate"},` +
+				`"ex:search":{"href":"/orders{?q}","templated":true},"self":{"href":"/orders/1"}},` +
+				`"_embedded":{"ex:customer":{"_links":{"self":{"href":"/customers/1"}},"name":"bob"}},"amount":100}`,
+		},
+		{"E", NewBuilder().EmbedAll("item").Links("ex:next"), nil, `{"_links":{"ex:next":[]},"_embedded":{"item":[]}}`},
+		{"F", NewBuilder().EmbedAll("item", child), nil, `{"_embedded":{"item":[{"_links":{"self":{"href":"/customers/1"}},"name":"bob"}]}}`},
+		{"G", NewBuilder().Self(" ").Prop("amount", 100), ErrNameEmpty, ""},
+		{"H", NewBuilder().Link("ex:mandate", nil), ErrPropMandatory, ""},
+		{"I", NewBuilder().Self("/orders/1").Template(DefaultTemplate, nil), ErrPropMandatory, ""},
+		{"J", NewBuilder().Template(DefaultTemplate, &Template{}), errorTemplateNoMethod, ""},
+	}
+	for _, v := range data {
+		r, err := v.in.Build()
+		if err != v.outErr {
+			t.Error("for", v.title, "waiting", v.outErr, "got", err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		out, _ := json.Marshal(r)
+		if string(out) != v.out {
+			t.Error("for", v.title, "waiting", v.out, "got", string(out))
+		}
+	}
+}
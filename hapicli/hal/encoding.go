@@ -0,0 +1,352 @@
+package hal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrInvalidTarget = errors.New("Hal: Unmarshal needs a non-nil pointer to a struct")
+	ErrInvalidSource = errors.New("Hal: Marshal needs a struct or a pointer to a struct")
+)
+
+var (
+	linkType     = reflect.TypeOf(Link{})
+	resourceType = reflect.TypeOf(Resource{})
+)
+
+// Unmarshal parses the HAL document and stores it in the struct pointed
+// to by v. The fields are filled like encoding/json does from the
+// properties of the resource, except the fields with a hal tag:
+//
+//	type Order struct {
+//		Amount   int         `json:"amount"`
+//		Self     hal.Link    `hal:"link,self"`
+//		Mandate  *hal.Link   `hal:"link,ex:mandate"`
+//		Debtor   Debtor      `hal:"embedded,ex:debtor"`
+//		Items    []Item      `hal:"embedded,items"`
+//	}
+//
+// The "link" fields are a Link, a *Link or a []Link filled from "_links".
+// The "embedded" fields are a struct, a pointer to a struct or a slice of
+// them, filled from "_embedded" recursively, or a *Resource or a []*Resource.
+// The rels missing from the document leave their fields untouched.
+func Unmarshal(data []byte, v interface{}) error {
+	r, err := NewRessourcefromJson(data)
+	if err != nil {
+		return err
+	}
+	return UnmarshalResource(r, v)
+}
+
+// UnmarshalResource stores the resource in the struct pointed to by v,
+// see Unmarshal.
+func UnmarshalResource(r *Resource, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidTarget
+	}
+	return decodeResource(r, rv.Elem())
+}
+
+// Marshal returns the HAL document of the struct v, see Unmarshal for
+// the hal tags. The properties are encoded like encoding/json does.
+func Marshal(v interface{}) ([]byte, error) {
+	r, err := MarshalResource(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(r)
+}
+
+// MarshalResource returns the resource of the struct v, see Marshal.
+func MarshalResource(v interface{}) (*Resource, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrInvalidSource
+	}
+	return encodeResource(rv)
+}
+
+// field is a field of a struct with its hal or json tag.
+type field struct {
+	value reflect.Value
+	// "link", "embedded" or "" for a property.
+	kind string
+	// The rel or the property name.
+	name      string
+	omitEmpty bool
+}
+
+// fields returns the fields of the struct, the anonymous
+// struct fields without tags being flattened.
+func fields(rv reflect.Value) ([]field, error) {
+	var out []field
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := rv.Field(i)
+		halTag, hasHal := sf.Tag.Lookup("hal")
+		jsonTag, hasJSON := sf.Tag.Lookup("json")
+		if sf.Anonymous && !hasHal && !hasJSON && sf.Type.Kind() == reflect.Struct {
+			sub, err := fields(fv)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, sub...)
+			continue
+		}
+		if sf.PkgPath != "" || jsonTag == "-" {
+			continue
+		}
+		if hasHal {
+			parts := strings.SplitN(halTag, ",", 2)
+			if len(parts) != 2 || (parts[0] != "link" && parts[0] != "embedded") || parts[1] == "" {
+				return nil, fmt.Errorf("Hal: invalid tag %q of the field %s", halTag, sf.Name)
+			}
+			out = append(out, field{value: fv, kind: parts[0], name: parts[1]})
+			continue
+		}
+		name, opts := sf.Name, ""
+		if hasJSON {
+			parts := strings.SplitN(jsonTag, ",", 2)
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			if len(parts) == 2 {
+				opts = parts[1]
+			}
+		}
+		out = append(out, field{value: fv, name: name, omitEmpty: strings.Contains(opts, "omitempty")})
+	}
+	return out, nil
+}
+
+// decodeResource fills the struct rv from the resource.
+func decodeResource(r *Resource, rv reflect.Value) error {
+	fs, err := fields(rv)
+	if err != nil {
+		return err
+	}
+	for _, f := range fs {
+		switch f.kind {
+		case "link":
+			err = decodeLinks(r, f)
+		case "embedded":
+			err = decodeEmbedded(r, f)
+		default:
+			err = decodeProperty(r, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeProperty fills the field from the property
+// matching its name in a case-insensitive fashion.
+func decodeProperty(r *Resource, f field) error {
+	v, ok := r.state[f.name]
+	if !ok {
+		for k, w := range r.state {
+			if strings.EqualFold(k, f.name) {
+				v, ok = w, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, f.value.Addr().Interface())
+}
+
+func decodeLinks(r *Resource, f field) error {
+	ls, err := r.Links(f.name)
+	if err != nil || len(ls) == 0 {
+		return nil
+	}
+	switch f.value.Type() {
+	case linkType:
+		f.value.Set(reflect.ValueOf(ls[0]))
+	case reflect.PtrTo(linkType):
+		l := ls[0]
+		f.value.Set(reflect.ValueOf(&l))
+	case reflect.SliceOf(linkType):
+		f.value.Set(reflect.ValueOf(append([]Link(nil), ls...)))
+	default:
+		return fmt.Errorf("Hal: the link field of %s must be a Link, a *Link or a []Link", f.name)
+	}
+	return nil
+}
+
+func decodeEmbedded(r *Resource, f field) error {
+	ers, err := r.EmbeddedResources(f.name)
+	if err != nil || len(ers) == 0 {
+		return nil
+	}
+	t := f.value.Type()
+	switch {
+	case t == reflect.PtrTo(resourceType):
+		if len(ers) != 1 {
+			return ErrEmbeddedNotUnique
+		}
+		f.value.Set(reflect.ValueOf(ers[0]))
+	case t == reflect.SliceOf(reflect.PtrTo(resourceType)):
+		f.value.Set(reflect.ValueOf(append([]*Resource(nil), ers...)))
+	case t.Kind() == reflect.Slice:
+		s := reflect.MakeSlice(t, len(ers), len(ers))
+		for i, er := range ers {
+			if err := decodeStruct(er, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		f.value.Set(s)
+	default:
+		if len(ers) != 1 {
+			return ErrEmbeddedNotUnique
+		}
+		return decodeStruct(ers[0], f.value)
+	}
+	return nil
+}
+
+// decodeStruct fills a struct or a pointer to a struct from the resource.
+func decodeStruct(r *Resource, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("Hal: an embedded field must be a struct, not %s", v.Type())
+	}
+	return decodeResource(r, v)
+}
+
+// encodeResource builds the resource of the struct rv.
+func encodeResource(rv reflect.Value) (*Resource, error) {
+	fs, err := fields(rv)
+	if err != nil {
+		return nil, err
+	}
+	r := new(Resource)
+	r.init()
+	for _, f := range fs {
+		switch f.kind {
+		case "link":
+			err = encodeLinks(r, f)
+		case "embedded":
+			err = encodeEmbedded(r, f)
+		default:
+			err = encodeProperty(r, f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func encodeProperty(r *Resource, f field) error {
+	if f.omitEmpty && isEmpty(f.value) {
+		return nil
+	}
+	data, err := json.Marshal(f.value.Interface())
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	r.SetProperty(f.name, v)
+	return nil
+}
+
+func encodeLinks(r *Resource, f field) error {
+	switch f.value.Type() {
+	case linkType:
+		if l := f.value.Interface().(Link); l.href != "" {
+			r.AddLink(f.name, l)
+		}
+	case reflect.PtrTo(linkType):
+		if l := f.value.Interface().(*Link); l != nil {
+			r.AddLink(f.name, *l)
+		}
+	case reflect.SliceOf(linkType):
+		if ls := f.value.Interface().([]Link); ls != nil {
+			r.AddLinks(f.name, ls...)
+		}
+	default:
+		return fmt.Errorf("Hal: the link field of %s must be a Link, a *Link or a []Link", f.name)
+	}
+	return nil
+}
+
+func encodeEmbedded(r *Resource, f field) error {
+	v := f.value
+	switch {
+	case v.Type() == reflect.PtrTo(resourceType):
+		if !v.IsNil() {
+			r.AddEmbeddedResource(f.name, v.Interface().(*Resource))
+		}
+	case v.Type() == reflect.SliceOf(reflect.PtrTo(resourceType)):
+		if !v.IsNil() {
+			r.AddEmbeddedResources(f.name, v.Interface().([]*Resource)...)
+		}
+	case v.Kind() == reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		ers := make([]*Resource, v.Len())
+		for i := range ers {
+			er, err := MarshalResource(v.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+			ers[i] = er
+		}
+		r.AddEmbeddedResources(f.name, ers...)
+	case v.Kind() == reflect.Ptr && v.IsNil():
+	default:
+		er, err := MarshalResource(v.Interface())
+		if err != nil {
+			return err
+		}
+		r.AddEmbeddedResource(f.name, er)
+	}
+	return nil
+}
+
+// isEmpty tells if the value is empty as defined by the omitempty
+// option of encoding/json.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package hal
+
+import (
+	"testing"
+)
+
+type testCustomer struct {
+	Name string `json:"name"`
+	Self Link   `hal:"link,self"`
+}
+
+type testItem struct {
+	Sku      string `json:"sku"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+type testOrder struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+	Status   string
+	Internal string        `json:"-"`
+	Self     Link          `hal:"link,self"`
+	Mandate  *Link         `hal:"link,ex:mandate"`
+	Payments []Link        `hal:"link,ex:payment"`
+	Customer *testCustomer `hal:"embedded,ex:customer"`
+	Items    []testItem    `hal:"embedded,items"`
+	Raw      *Resource     `hal:"embedded,ex:raw"`
+}
+
+const testOrderJSON = `{"_links":{"ex:mandate":{"href":"/mandates/1"},"ex:payment":[{"href":"/payments/1"}],"self":{"href":"/orders/1"}},` +
+	`"_embedded":{"ex:customer":{"_links":{"self":{"href":"/customers/1"}},"name":"bob"},` +
+	`"ex:raw":{"id":1},"items":[{"quantity":2,"sku":"A"},{"sku":"B"}]},` +
+	`"Status":"paid","amount":100,"currency":"EUR"}`
+
+func TestUnmarshal(t *testing.T) {
+	var o testOrder
+	if err := Unmarshal([]byte(testOrderJSON), &o); err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if o.Amount != 100 || o.Currency != "EUR" || o.Status != "paid" {
+		t.Error("waiting the properties got", o)
+	}
+	if o.Self.Href() != "/orders/1" || o.Mandate == nil || o.Mandate.Href() != "/mandates/1" {
+		t.Error("waiting the links got", o.Self, o.Mandate)
+	}
+	if len(o.Payments) != 1 || o.Payments[0].Href() != "/payments/1" {
+		t.Error("waiting one payment link got", o.Payments)
+	}
+	if o.Customer == nil || o.Customer.Name != "bob" || o.Customer.Self.Href() != "/customers/1" {
+		t.Error("waiting the embedded customer got", o.Customer)
+	}
+	if len(o.Items) != 2 || o.Items[0].Sku != "A" || o.Items[0].Quantity != 2 || o.Items[1].Sku != "B" {
+		t.Error("waiting 2 items got", o.Items)
+	}
+	if o.Raw == nil || o.Raw.State()["id"] != float64(1) {
+		t.Error("waiting the raw resource got", o.Raw)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var o testOrder
+	data := []struct {
+		title string
+		in    string
+		v     interface{}
+		out   error
+	}{
+		{"A", testOrderJSON, o, ErrInvalidTarget},
+		{"B", testOrderJSON, (*testOrder)(nil), ErrInvalidTarget},
+		{"C", `{"_embedded":{"ex:customer":[{"name":"a"},{"name":"b"}]}}`, &o, ErrEmbeddedNotUnique},
+		{"D", `{"amount":1}`, &o, nil},
+		{"E", `{"_embedded":{"ex:raw":[{"id":1},{"id":2}]}}`, &o, ErrEmbeddedNotUnique},
+	}
+	for _, v := range data {
+		if err := Unmarshal([]byte(v.in), v.v); err != v.out {
+			t.Error("for", v.title, "waiting", v.out, "got", err)
+		}
+	}
+	var bad struct {
+		Self Link `hal:"self"`
+	}
+	if err := Unmarshal([]byte(testOrderJSON), &bad); err == nil {
+		t.Error("waiting an error for an invalid tag got nil")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	var o testOrder
+	if err := Unmarshal([]byte(testOrderJSON), &o); err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	out, err := Marshal(o)
+	if err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if string(out) != testOrderJSON {
+		t.Error("waiting", testOrderJSON, "got", string(out))
+	}
+	out, _ = Marshal(&testItem{Sku: "C"})
+	if string(out) != `{"sku":"C"}` {
+		t.Error("waiting", `{"sku":"C"}`, "got", string(out))
+	}
+	if _, err := Marshal("order"); err != ErrInvalidSource {
+		t.Error("waiting", ErrInvalidSource, "got", err)
+	}
+}
+
+func TestMarshalResources(t *testing.T) {
+	type list struct {
+		Items []*Resource `hal:"embedded,items"`
+		Raw   *Resource   `hal:"embedded,ex:raw"`
+	}
+	in := `{"_embedded":{"ex:raw":{"_links":{"self":{"href":"/raw"}},"id":0},"items":[{"_links":{"self":{"href":"/items/1"}},"id":1},{"id":2}]}}`
+	var l list
+	if err := Unmarshal([]byte(in), &l); err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if len(l.Items) != 2 || l.Items[0].State()["id"] != float64(1) || l.Items[1].State()["id"] != float64(2) {
+		t.Error("waiting the 2 embedded resources got", l.Items)
+	}
+	if self, _ := l.Items[0].Link("self"); self == nil || self.Href() != "/items/1" {
+		t.Error("waiting the self link of the first item got", self)
+	}
+	out, err := Marshal(l)
+	if err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if string(out) != in {
+		t.Error("waiting", in, "got", string(out))
+	}
+}
@@ -11,7 +11,10 @@ var (
 	ErrPropMandatory = errors.New("The href property is mandatory.")
 )
 
-type link struct {
+// The Link Object described in the
+// JSON Hypertext Application Language (draft-kelly-json-hal-07)
+// see https://tools.ietf.org/html/draft-kelly-json-hal-07#section-5
+type Link struct {
 	// REQUIRED
 	// Its value is either a URI [RFC3986] or a URI Template [RFC6570].<br>
 	// If the value is a URI Template then the Link Object SHOULD have a
@@ -77,57 +80,57 @@ type LinkOptionalParam struct {
 // - href			string
 // optional params :
 // - lop LinkOptionalParam
-func NewLink(href string, lop LinkOptionalParam) (*link, error) {
+func NewLink(href string, lop LinkOptionalParam) (*Link, error) {
 	href, err := trimSpace(href)
 	if err != nil {
 		return nil, err
 	}
-	l := link{href, lop.Templated, lop.MediaType, lop.Deprecation, lop.Name, lop.Profile, lop.Title, lop.Hreflang}
+	l := Link{href, lop.Templated, lop.MediaType, lop.Deprecation, lop.Name, lop.Profile, lop.Title, lop.Hreflang}
 	return &l, nil
 }
 
 // Href get the href link property
-func (l *link) Href() string {
+func (l *Link) Href() string {
 	return l.href
 }
 
 // Templated get the templated link property
-func (l *link) Templated() bool {
+func (l *Link) Templated() bool {
 	return l.templated
 }
 
 // Type get the mediaType link property
-func (l *link) Type() string {
+func (l *Link) Type() string {
 	return l.mediaType
 }
 
 // Deprecation get the deprecation link property
-func (l *link) Deprecation() string {
+func (l *Link) Deprecation() string {
 	return l.deprecation
 }
 
 // Name get the name link property
-func (l *link) Name() string {
+func (l *Link) Name() string {
 	return l.name
 }
 
 // Profile get the profile link property
-func (l *link) Profile() string {
+func (l *Link) Profile() string {
 	return l.profile
 }
 
 // Title get the profile link property
-func (l *link) Title() string {
+func (l *Link) Title() string {
 	return l.title
 }
 
 // Hreflang get the hreflang link property
-func (l *link) Hreflang() string {
+func (l *Link) Hreflang() string {
 	return l.hreflang
 }
 
 // SetHref is setting the href of link property
-func (l *link) SetHref(h string) error {
+func (l *Link) SetHref(h string) error {
 	h, err := trimSpace(h)
 	if err != nil {
 		return err
@@ -137,43 +140,43 @@ func (l *link) SetHref(h string) error {
 }
 
 // SetTemplated is setting the templated of link property
-func (l *link) SetTemplated(t bool) {
+func (l *Link) SetTemplated(t bool) {
 	l.templated = t
 }
 
 // SetType is setting the mediaType of link property
-func (l *link) SetType(t string) {
+func (l *Link) SetType(t string) {
 	l.mediaType = t
 }
 
 // SetDeprecation is setting the deprecation of link property
-func (l *link) SetDeprecation(d string) {
+func (l *Link) SetDeprecation(d string) {
 	l.deprecation = d
 }
 
 // SetName is setting the name of link property
-func (l *link) SetName(n string) {
+func (l *Link) SetName(n string) {
 	l.name = n
 }
 
 // SetProfile is setting the profile of link property
-func (l *link) SetProfile(p string) {
+func (l *Link) SetProfile(p string) {
 	l.profile = p
 }
 
 // SetTitle is setting the title of link property
-func (l *link) SetTitle(p string) {
+func (l *Link) SetTitle(p string) {
 	l.title = p
 }
 
 // SetHreflang is setting the hreflang of link property
-func (l *link) SetHreflang(h string) {
+func (l *Link) SetHreflang(h string) {
 	l.hreflang = h
 }
 
 // NewLinkFromJson is creating a link from a json
 // this will trow an error if the JSON is not valid
-func NewLinkFromJson(data []byte) (*link, error) {
+func NewLinkFromJson(data []byte) (*Link, error) {
 	var aux struct {
 		Href        string `json:"href"`
 		Templated   bool   `json:"templated"`
@@ -187,9 +190,9 @@ func NewLinkFromJson(data []byte) (*link, error) {
 
 	dec := json.NewDecoder(bytes.NewReader(data))
 	if err := dec.Decode(&aux); err != nil {
-		return nil, fmt.Errorf("JSON must be a string, an array or an object : ", err)
+		return nil, fmt.Errorf("JSON must be a string, an array or an object : %v", err)
 	}
-	l := link{
+	l := Link{
 		aux.Href,
 		aux.Templated,
 		aux.MediaType,
@@ -201,8 +204,24 @@ func NewLinkFromJson(data []byte) (*link, error) {
 	return &l, nil
 }
 
+// MarshalJSON returns the JSON representation of the Link Object,
+// without the optional properties which are not set.
+func (l Link) MarshalJSON() ([]byte, error) {
+	aux := struct {
+		Href        string `json:"href"`
+		Templated   bool   `json:"templated,omitempty"`
+		MediaType   string `json:"type,omitempty"`
+		Deprecation string `json:"deprecation,omitempty"`
+		Name        string `json:"name,omitempty"`
+		Profile     string `json:"profile,omitempty"`
+		Title       string `json:"title,omitempty"`
+		Hreflang    string `json:"hreflang,omitempty"`
+	}{l.href, l.templated, l.mediaType, l.deprecation, l.name, l.profile, l.title, l.hreflang}
+	return json.Marshal(aux)
+}
+
 // String is using the interface of strings for all usages toString()
-func (l *link) String() string {
+func (l *Link) String() string {
 	s := "(href=" + l.href
 
 	b := "false"
@@ -10,14 +10,14 @@ func TestNewLink(t *testing.T) {
 		title      string
 		inHref     string
 		inOptional LinkOptionalParam
-		outLink    link
+		outLink    Link
 		outErr     error
 	}{
 		{
 			"A",
 			"test",
 			LinkOptionalParam{true, "pdf", "no", "the name", "profile", "title", "golang"},
-			link{"test", true, "pdf", "no", "the name", "profile", "title", "golang"},
+			Link{"test", true, "pdf", "no", "the name", "profile", "title", "golang"},
 			nil,
 		},
 
@@ -25,7 +25,7 @@ func TestNewLink(t *testing.T) {
 			"B",
 			"",
 			LinkOptionalParam{},
-			link{},
+			Link{},
 			ErrNameEmpty,
 		},
 	}
@@ -44,7 +44,7 @@ func TestNewLinkFromJson(t *testing.T) {
 	data := []struct {
 		title   string
 		in      []byte
-		outLink link
+		outLink Link
 		outErr  error
 	}{
 		{
@@ -59,7 +59,7 @@ func TestNewLinkFromJson(t *testing.T) {
 			    "title": "title B",
 			    "hreflang": "java"
   				}`),
-			link{"http:www.greentic.com", false, "blue", "no", "fred", "man", "title B", "java"},
+			Link{"http:www.greentic.com", false, "blue", "no", "fred", "man", "title B", "java"},
 			nil,
 		},
 	}
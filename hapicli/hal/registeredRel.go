@@ -459,16 +459,13 @@ const (
 
 type RegisteredRel string
 
-func (r *RegisteredRel) RegisteredRel(name string) {
-	r = name
+// Name returns the relation name.
+func (r RegisteredRel) Name() string {
+	return string(r)
 }
 
-func (r *RegisteredRel) Name() string {
-	return r
-}
-
-func (r *RegisteredRel) String() string {
-	return "RegisteredRel [name=" + r + "]"
+func (r RegisteredRel) String() string {
+	return "RegisteredRel [name=" + string(r) + "]"
 }
 
 // param name	The Registered relation name.
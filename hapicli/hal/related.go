@@ -0,0 +1,93 @@
+package hal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrPartialResource = errors.New("Hal: the embedded resource is partial and has no self link")
+)
+
+// Fetcher gets the resource targeted by a link,
+// e.g. the hapicli.Client.
+type Fetcher interface {
+	Fetch(ctx context.Context, l *Link) (*Resource, error)
+}
+
+// Finds a unique related resource by its relation type.
+// See RelatedResources.
+// param rel		string		The relation type.
+// param f			Fetcher		Gets the resource when it is not embedded.
+// param required	[]string	The properties of a complete representation.
+// return	Resource	The Resource related by the given rel.
+// throws ErrEmbeddedNotUnique
+// throws ErrLinkNotUnique
+// throws ErrRelNotFound
+func (r *Resource) Related(ctx context.Context, rel string, f Fetcher, required ...string) (*Resource, error) {
+	if ers, err := r.EmbeddedResources(rel); err == nil {
+		if len(ers) != 1 {
+			return nil, ErrEmbeddedNotUnique
+		}
+	} else if ls, err := r.Links(rel); err == nil && len(ls) != 1 {
+		return nil, ErrLinkNotUnique
+	}
+	rs, err := r.RelatedResources(ctx, rel, f, required...)
+	if err != nil {
+		return nil, err
+	}
+	return rs[0], nil
+}
+
+// Finds an array of related resources by their relation type.
+// The embedded resources are returned when the resource embeds the rel,
+// otherwise the links of the rel are fetched.
+// An embedded resource missing one of the required properties is
+// a partial representation: the complete one is fetched from its self link.
+// param rel		string		The relation type.
+// param f			Fetcher		Gets the resources which are not embedded.
+// param required	[]string	The properties of a complete representation.
+// return	Array of resources related by the given rel.
+// throws ErrPartialResource
+// throws ErrRelNotFound
+func (r *Resource) RelatedResources(ctx context.Context, rel string, f Fetcher, required ...string) ([]*Resource, error) {
+	if ers, err := r.EmbeddedResources(rel); err == nil {
+		out := make([]*Resource, len(ers))
+		for i, er := range ers {
+			if !er.isPartial(required) {
+				out[i] = er
+				continue
+			}
+			self, err := er.Link(SELF.Name())
+			if err != nil {
+				return nil, ErrPartialResource
+			}
+			if out[i], err = f.Fetch(ctx, self); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+
+	ls, err := r.Links(rel)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Resource, len(ls))
+	for i := range ls {
+		if out[i], err = f.Fetch(ctx, &ls[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// isPartial tells if one of the required properties is missing from the state.
+func (r *Resource) isPartial(required []string) bool {
+	for _, p := range required {
+		if _, ok := r.state[p]; !ok {
+			return true
+		}
+	}
+	return false
+}
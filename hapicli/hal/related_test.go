@@ -0,0 +1,68 @@
+package hal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeFetcher builds the resources from the JSON served for each href.
+type fakeFetcher struct {
+	docs    map[string]string
+	fetched []string
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, l *Link) (*Resource, error) {
+	f.fetched = append(f.fetched, l.Href())
+	doc, ok := f.docs[l.Href()]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return NewRessourcefromJson([]byte(doc))
+}
+
+func TestRelated(t *testing.T) {
+	f := &fakeFetcher{docs: map[string]string{
+		"https://example.com/api/customer/1234":         `{"name":"bob"}`,
+		"https://example.com/api/customer/123456?users": `{"name":"users"}`,
+		"https://example.com/user/11":                   `{"name":"Example User","email":"user@example.com"}`,
+	}}
+	data := []struct {
+		title      string
+		inFile     string
+		inRel      string
+		inRequired []string
+		outName    string
+		outFetched int
+		outErr     error
+	}{
+		{"A", "exampleWithSubresource.json", "ns:user", nil, "Example User", 0, nil},
+		{"B", "exampleWithSubresource.json", "ns:parent", nil, "bob", 1, nil},
+		{"C", "exampleWithSubresource.json", "ns:user", []string{"email"}, "Example User", 1, nil},
+		{"D", "exampleWithSubresource.json", "ns:unknown", nil, "", 0, ErrRelNotFound},
+		{"E", "example.json", "curies", nil, "", 0, ErrLinkNotUnique},
+		{"F", "exampleWithSubresource.json", "NS:Users", nil, "users", 1, nil},
+	}
+	for _, v := range data {
+		f.fetched = nil
+		r := loadResource(t, v.inFile)
+		rr, err := r.Related(context.Background(), v.inRel, f, v.inRequired...)
+		if err != v.outErr {
+			t.Error("for", v.title, "waiting", v.outErr, "got", err)
+		}
+		if err == nil && rr.State()["name"] != v.outName {
+			t.Error("for", v.title, "waiting", v.outName, "got", rr.State()["name"])
+		}
+		if len(f.fetched) != v.outFetched {
+			t.Error("for", v.title, "waiting", v.outFetched, "fetches got", f.fetched)
+		}
+	}
+}
+
+func TestRelatedPartialWithoutSelf(t *testing.T) {
+	r, _ := NewRessourcefromJson([]byte(`{"_embedded":{"item":[{"id":1},{"id":2,"name":"two"}]}}`))
+	_, err := r.RelatedResources(context.Background(), "item", &fakeFetcher{}, "name")
+	if err != ErrPartialResource {
+		t.Error("waiting", ErrPartialResource, "got", err)
+	}
+}
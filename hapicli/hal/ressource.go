@@ -5,9 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
+var (
+	ErrRelNotFound            = errors.New("Hal: Rel not found")
+	ErrLinkNotUnique          = errors.New("Hal: the link is not unique for this rel")
+	ErrEmbeddedNotUnique      = errors.New("Hal: the embedded resource is not unique for this rel")
+	ErrNoEmbeddedResources    = errors.New("Hal: there is no embedded Resources")
+	errorReservedPropertyType = errors.New("Hal: _links and _embedded must be JSON objects")
+)
+
 // The Resource Object described in the
 // JSON Hypertext Application Language (draft-kelly-json-hal-07)
 // see https://tools.ietf.org/html/draft-kelly-json-hal-07#section-4
@@ -19,139 +28,452 @@ import (
 // "When extension relation types are compared, they MUST be compared as
 // strings [...] in a case-insensitive fashion."
 // see https://tools.ietf.org/html/rfc5988#section-4.2
-type resource struct {
-	state             []string
-	links             map[string]link
-	embeddedResources map[string]string
+type Resource struct {
+	state             map[string]interface{}
+	links             map[string][]Link
+	embeddedResources map[string][]*Resource
+
+	// The rels whose links or embedded resources are
+	// represented by an array, even of a single element.
+	linkArrays     map[string]bool
+	embeddedArrays map[string]bool
+
+	// The HAL-FORMS templates of the resource by key.
+	templates map[string]*Template
+
+	// The validators of the representation the resource was built from,
+	// as sent by the server in the ETag and Last-Modified headers.
+	// They are empty when the resource was not fetched over HTTP.
+	etag         string
+	lastModified string
 }
 
-// NewResource is creating a resource or an error if some params are nil
-func NewResource(st []string, ls map[string]link, er map[string]string) (*resource, error) {
+// NewResource is creating a resource or an error if some params are nil.
+// Use NewBuilder to build a resource of any shape.
+func NewResource(st map[string]interface{}, ls map[string][]Link, er map[string][]*Resource) (*Resource, error) {
 	if len(st) == 0 || len(ls) == 0 || len(er) == 0 {
 		return nil, errors.New("Hal: please fill all params")
 	}
-	r := resource{st, ls, er}
+	r := Resource{state: st, links: ls, embeddedResources: er}
 	return &r, nil
 }
 
 // All the properties of the resource
 // ("_links" and "_embedded" not included).
-// return	[]string
-func (r *resource) State() []string {
+// return	map[string]interface{}
+func (r *Resource) State() map[string]interface{} {
 	return r.state
 }
 
 // All the links directly available in the resource.
 // The key is the relation type (Rel) and the value
-// can be either a Link or a numeric array of Links.
+// is the list of Links of this relation type.
 //
 // Note that there is no guarantees as to the order of the links.
-// return	[]link
-func (r *resource) AllLinks() map[string]link {
+// return	map[string][]Link
+func (r *Resource) AllLinks() map[string][]Link {
 	return r.links
 }
 
-// Finds a unique embedded resource by its relation type.
-// param $rel	RegisteredRel|CustomRel		The relation type.
-// return	Resource	The Resource referenced by the given rel.
-// throws EmbeddedResourceNotUniqueException
-// throws RelNotFoundException
-func (r *resource) AllEmbeddedResources() (map[string]string, error) {
+// All the embedded resources directly available in the resource.
+// The key is the relation type (Rel) and the value
+// is the list of Resources of this relation type.
+// return	map[string][]*Resource
+// throws ErrNoEmbeddedResources
+func (r *Resource) AllEmbeddedResources() (map[string][]*Resource, error) {
 	if len(r.embeddedResources) == 0 {
-		return nil, errors.New("Hal: there is no embedded Resources")
+		return nil, ErrNoEmbeddedResources
 	}
 	return r.embeddedResources, nil
 }
 
 // Finds a unique link by its relation type.
-// param $rel	RegisteredRel|CustomRel		The relation type.
+// param rel	string		The relation type.
 // return	Link	The Link referenced by the given rel.
-// throws LinkNotUniqueException
-// throws RelNotFoundException
-func (r *resource) Link(rel string) (*link, error) {
-	l, ok := r.links[rel]
-	if !ok {
-		return nil, errors.New("Hal: Rel not found")
+// throws ErrLinkNotUnique
+// throws ErrRelNotFound
+func (r *Resource) Link(rel string) (*Link, error) {
+	ls, err := r.Links(rel)
+	if err != nil {
+		return nil, err
 	}
-	return &l, nil
+	if len(ls) != 1 {
+		return nil, ErrLinkNotUnique
+	}
+	return &ls[0], nil
 }
 
 // Finds an array of links by their relation type.
-// Note that there is no guarantees as to the order of the links.
-// param $rel	RegisteredRel|CustomRel		The relation type.
-// return	Numeric array of links referenced by the given rel
-// throws LinkUniqueException
-// throws RelNotFoundException
-func (r *resource) Links(rel string) (map[string]link, error) {
-	l, err := r.Link(rel)
-	ls := make(map[string]link)
-	ls[rel] = *l
+// param rel	string		The relation type.
+// return	Array of links referenced by the given rel
+// throws ErrRelNotFound
+func (r *Resource) Links(rel string) ([]Link, error) {
+	key, err := r.findByRel(linkRels(r.links), rel)
+	if err != nil {
+		return nil, err
+	}
+	return r.links[key], nil
+}
+
+// Finds a unique embedded resource by its relation type.
+// param rel	string		The relation type.
+// return	Resource	The Resource referenced by the given rel.
+// throws ErrEmbeddedNotUnique
+// throws ErrRelNotFound
+func (r *Resource) EmbeddedResource(rel string) (*Resource, error) {
+	ers, err := r.EmbeddedResources(rel)
 	if err != nil {
 		return nil, err
 	}
-	return ls, nil
+	if len(ers) != 1 {
+		return nil, ErrEmbeddedNotUnique
+	}
+	return ers[0], nil
 }
 
 // Finds an array of embedded resources by their relation type.
-// Note that there is no guarantees as to the order of the resources.
-// param $rel	RegisteredRel|CustomRel		The relation type.
-// return	Numeric array of embedded resources referenced by the given rel.
-// throws EmbeddedResourceUniqueException
-// throws RelNotFoundException
-func (r *resource) EmbeddedResources(rel string) (int, error) {
-	//todo
-	return 0, nil
+// param rel	string		The relation type.
+// return	Array of embedded resources referenced by the given rel.
+// throws ErrRelNotFound
+func (r *Resource) EmbeddedResources(rel string) ([]*Resource, error) {
+	key, err := r.findByRel(embeddedRels(r.embeddedResources), rel)
+	if err != nil {
+		return nil, err
+	}
+	return r.embeddedResources[key], nil
+}
+
+// ETag returns the entity tag of the representation
+// the resource was built from, if any.
+func (r *Resource) ETag() string {
+	return r.etag
+}
+
+// LastModified returns the Last-Modified date of the
+// representation the resource was built from, if any.
+func (r *Resource) LastModified() string {
+	return r.lastModified
+}
+
+// SetETag is setting the entity tag of the resource
+func (r *Resource) SetETag(e string) {
+	r.etag = e
+}
+
+// SetLastModified is setting the Last-Modified date of the resource
+func (r *Resource) SetLastModified(lm string) {
+	r.lastModified = lm
+}
+
+// SetProperty is setting a property of the state of the resource
+func (r *Resource) SetProperty(name string, v interface{}) {
+	r.init()
+	r.state[name] = v
+}
+
+// AddLink is adding a link of the given relation type,
+// represented by a Link Object unless the rel holds several links.
+func (r *Resource) AddLink(rel string, l Link) {
+	r.init()
+	rel = r.relKey(linkRels(r.links), rel)
+	r.links[rel] = append(r.links[rel], l)
+}
+
+// AddLinks is adding links of the given relation type,
+// represented by an array of Link Objects.
+func (r *Resource) AddLinks(rel string, ls ...Link) {
+	r.init()
+	rel = r.relKey(linkRels(r.links), rel)
+	r.links[rel] = append(r.links[rel], ls...)
+	if r.links[rel] == nil {
+		r.links[rel] = []Link{}
+	}
+	r.linkArrays[rel] = true
+}
+
+// AddEmbeddedResource is adding an embedded resource of the given relation
+// type, represented by a Resource Object unless the rel holds several ones.
+func (r *Resource) AddEmbeddedResource(rel string, er *Resource) {
+	r.init()
+	rel = r.relKey(embeddedRels(r.embeddedResources), rel)
+	r.embeddedResources[rel] = append(r.embeddedResources[rel], er)
+}
+
+// AddEmbeddedResources is adding embedded resources of the given
+// relation type, represented by an array of Resource Objects.
+func (r *Resource) AddEmbeddedResources(rel string, ers ...*Resource) {
+	r.init()
+	rel = r.relKey(embeddedRels(r.embeddedResources), rel)
+	r.embeddedResources[rel] = append(r.embeddedResources[rel], ers...)
+	if r.embeddedResources[rel] == nil {
+		r.embeddedResources[rel] = []*Resource{}
+	}
+	r.embeddedArrays[rel] = true
+}
+
+// RemoveLinks is removing the links of the given relation type
+func (r *Resource) RemoveLinks(rel string) {
+	if key, err := r.findByRel(linkRels(r.links), rel); err == nil {
+		delete(r.links, key)
+		delete(r.linkArrays, key)
+	}
+}
+
+// RemoveEmbeddedResources is removing the embedded resources
+// of the given relation type
+func (r *Resource) RemoveEmbeddedResources(rel string) {
+	if key, err := r.findByRel(embeddedRels(r.embeddedResources), rel); err == nil {
+		delete(r.embeddedResources, key)
+		delete(r.embeddedArrays, key)
+	}
+}
+
+// Clone returns a copy of the resource which can be changed without
+// changing r. The embedded resources are cloned too, the values
+// of the properties are shared.
+func (r *Resource) Clone() *Resource {
+	c := &Resource{etag: r.etag, lastModified: r.lastModified}
+	c.init()
+	for k, v := range r.state {
+		c.state[k] = v
+	}
+	for rel, ls := range r.links {
+		c.links[rel] = append([]Link{}, ls...)
+	}
+	for rel, ers := range r.embeddedResources {
+		c.embeddedResources[rel] = make([]*Resource, len(ers))
+		for i, er := range ers {
+			c.embeddedResources[rel][i] = er.Clone()
+		}
+	}
+	for rel, b := range r.linkArrays {
+		c.linkArrays[rel] = b
+	}
+	for rel, b := range r.embeddedArrays {
+		c.embeddedArrays[rel] = b
+	}
+	for key, t := range r.templates {
+		clone := *t
+		clone.Properties = append([]Property(nil), t.Properties...)
+		c.AddTemplate(key, &clone)
+	}
+	return c
+}
+
+// init makes the maps of a zero Resource.
+func (r *Resource) init() {
+	if r.state == nil {
+		r.state = make(map[string]interface{})
+	}
+	if r.links == nil {
+		r.links = make(map[string][]Link)
+	}
+	if r.embeddedResources == nil {
+		r.embeddedResources = make(map[string][]*Resource)
+	}
+	if r.linkArrays == nil {
+		r.linkArrays = make(map[string]bool)
+	}
+	if r.embeddedArrays == nil {
+		r.embeddedArrays = make(map[string]bool)
+	}
+}
+
+// relKey returns the key of table matching rel, or rel if none.
+func (r *Resource) relKey(table []string, rel string) string {
+	if key, err := r.findByRel(table, rel); err == nil {
+		return key
+	}
+	return rel
 }
 
 // Looks for the given relation name in a case-insensitive
-// fashion and returns the corresponding value.
-// return	mixed	The value in $a matching the relation name
-//					or null if not found.
-func (r *resource) findByRel(table []string, rel string) (int, error) {
-	for key, v := range table {
+// fashion and returns the corresponding key.
+// return	string	The value in table matching the relation name
+// throws ErrRelNotFound
+func (r *Resource) findByRel(table []string, rel string) (string, error) {
+	rel = strings.ToLower(rel)
+	for _, v := range table {
 		s := strings.ToLower(v)
 		if s == rel {
-			return key, nil
+			return v, nil
 		}
 	}
-	return 0, errors.New("Hal: the relation has not been found.")
+	return "", ErrRelNotFound
 }
 
 // Builds a Resource from its JSON representation.
-// param $json		string|array|object		A JSON representing the resource.
+// param data		[]byte		A JSON object representing the resource.
 // return	Resource
-func NewRessourcefromJson(data []byte) (*resource, error) {
-	var aux struct {
-		state             []string          `json:"state"`
-		links             map[string]link   `json:"links"`
-		embeddedResources map[string]string `json:"embeddedResources"`
-	}
+func NewRessourcefromJson(data []byte) (*Resource, error) {
+	var aux map[string]json.RawMessage
 
 	dec := json.NewDecoder(bytes.NewReader(data))
 	if err := dec.Decode(&aux); err != nil {
-		return nil, fmt.Errorf("JSON must be a string, an array or an object : %v", err)
+		return nil, fmt.Errorf("JSON must be an object : %v", err)
+	}
+	return newResourceFromMap(aux)
+}
+
+// newResourceFromMap builds a Resource from the members of its JSON object.
+func newResourceFromMap(aux map[string]json.RawMessage) (*Resource, error) {
+	var r Resource
+	r.init()
+	for k, v := range aux {
+		var err error
+		switch k {
+		case "_links":
+			err = r.extractLinks(v)
+		case "_embedded":
+			err = r.extractEmbedded(v)
+		case "_templates":
+			err = r.extractTemplates(v)
+		default:
+			var p interface{}
+			err = json.Unmarshal(v, &p)
+			r.state[k] = p
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
-	r := resource{aux.state, aux.links, aux.embeddedResources}
 	return &r, nil
 }
 
-func (r resource) extractState(data []byte) []string {
-	//todo
-	return r.state
+// extractLinks fills the links of the resource from the "_links" member.
+// Each rel holds either a Link Object or an array of Link Objects.
+func (r *Resource) extractLinks(data []byte) error {
+	var rels map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rels); err != nil || rels == nil {
+		return errorReservedPropertyType
+	}
+	for rel, raw := range rels {
+		items, isArray := splitArray(raw)
+		r.linkArrays[rel] = isArray
+		for _, item := range items {
+			l, err := NewLinkFromJson(item)
+			if err != nil {
+				return err
+			}
+			r.links[rel] = append(r.links[rel], *l)
+		}
+	}
+	return nil
 }
 
-func (r resource) extractByRel(data []byte, rel string) ([]string, error) {
-	var out []string
-	if len(rel) == 0 {
-		return nil, errors.New("Hal: please fill a ref relation in param")
+// extractEmbedded fills the embedded resources from the "_embedded" member.
+// Each rel holds either a Resource Object or an array of Resource Objects.
+func (r *Resource) extractEmbedded(data []byte) error {
+	var rels map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rels); err != nil || rels == nil {
+		return errorReservedPropertyType
+	}
+	for rel, raw := range rels {
+		items, isArray := splitArray(raw)
+		r.embeddedArrays[rel] = isArray
+		for _, item := range items {
+			er, err := NewRessourcefromJson(item)
+			if err != nil {
+				return err
+			}
+			r.embeddedResources[rel] = append(r.embeddedResources[rel], er)
+		}
 	}
+	return nil
+}
 
-	return out, nil
+// splitArray returns the elements of a JSON array,
+// or the value itself when it is not an array.
+func splitArray(data json.RawMessage) ([]json.RawMessage, bool) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return []json.RawMessage{data}, false
+	}
+	return items, true
+}
+
+// MarshalJSON returns the JSON representation of the resource:
+// its "_links", its "_embedded" resources, its "_templates"
+// and its properties.
+// A rel holding a single link or embedded resource is represented
+// by an object, unless it was built from an array.
+func (r *Resource) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	sep := ""
+	member := func(k string, v interface{}) error {
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(sep)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+		sep = ","
+		return nil
+	}
+
+	if len(r.links) > 0 {
+		links := make(map[string]interface{}, len(r.links))
+		for rel, ls := range r.links {
+			if len(ls) == 1 && !r.linkArrays[rel] && rel != CURIES.Name() {
+				links[rel] = &ls[0]
+				continue
+			}
+			links[rel] = ls
+		}
+		if err := member("_links", links); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.embeddedResources) > 0 {
+		embedded := make(map[string]interface{}, len(r.embeddedResources))
+		for rel, ers := range r.embeddedResources {
+			if len(ers) == 1 && !r.embeddedArrays[rel] {
+				embedded[rel] = ers[0]
+				continue
+			}
+			embedded[rel] = ers
+		}
+		if err := member("_embedded", embedded); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.templates) > 0 {
+		if err := member("_templates", r.templates); err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]string, 0, len(r.state))
+	for k := range r.state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := member(k, r.state[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// linkRels returns the relation types of the links.
+func linkRels(m map[string][]Link) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
 }
 
-// copyMap is copiing a map A to a map B
-func copyMap(mapA map[string]string, mapB map[string]string) {
-	for k, v := range mapA {
-		mapB[k] = v
+// embeddedRels returns the relation types of the embedded resources.
+func embeddedRels(m map[string][]*Resource) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
 	}
+	return out
 }
@@ -1,15 +1,42 @@
 package hal
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
+// loadResource builds a Resource from a file of the testdata directory.
+func loadResource(t *testing.T, name string) *Resource {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRessourcefromJson(data)
+	if err != nil {
+		t.Fatal("for", name, "waiting no error got", err)
+	}
+	return r
+}
+
 func TestNewResource(t *testing.T) {
 
 }
 
 func TestState(t *testing.T) {
-
+	r := loadResource(t, "exampleWithNullProperty.json")
+	st := r.State()
+	if len(st) != 6 {
+		t.Error("waiting 6 properties got", st)
+	}
+	if v, ok := st["nullprop"]; !ok || v != nil {
+		t.Error("waiting a null nullprop got", v)
+	}
+	if st["name"] != "Example Resource" {
+		t.Error("waiting Example Resource got", st["name"])
+	}
 }
 
 func TestAllLinks(t *testing.T) {
@@ -21,15 +48,58 @@ func TestAllEmbeddedResources(t *testing.T) {
 }
 
 func TestLink(t *testing.T) {
-
+	data := []struct {
+		title   string
+		inRel   string
+		outHref string
+		outErr  error
+	}{
+		{"A", "self", "https://example.com/api/customer/123456", nil},
+		{"B", "NS:Parent", "https://example.com/api/customer/1234", nil},
+		{"C", "curies", "", ErrLinkNotUnique},
+		{"D", "ns:unknown", "", ErrRelNotFound},
+	}
+	r := loadResource(t, "example.json")
+	for _, v := range data {
+		l, err := r.Link(v.inRel)
+		if err != v.outErr {
+			t.Error("for", v.title, "waiting", v.outErr, "got", err)
+		}
+		if err == nil && l.Href() != v.outHref {
+			t.Error("for", v.title, "waiting", v.outHref, "got", l.Href())
+		}
+	}
 }
 
 func TestLinks(t *testing.T) {
-
+	r := loadResource(t, "exampleSingleElemArrayLinks.json")
+	ls, err := r.Links("ns:users")
+	if err != nil || len(ls) != 1 {
+		t.Error("waiting 1 link got", ls, err)
+	}
+	r = loadResource(t, "example.json")
+	ls, err = r.Links("curies")
+	if err != nil || len(ls) != 2 || ls[1].Name() != "role" {
+		t.Error("waiting 2 curies got", ls, err)
+	}
 }
 
 func TestEmbeddedResources(t *testing.T) {
-
+	r := loadResource(t, "exampleWithSubresource.json")
+	er, err := r.EmbeddedResource("ns:user")
+	if err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if er.State()["name"] != "Example User" {
+		t.Error("waiting Example User got", er.State()["name"])
+	}
+	if _, err := r.EmbeddedResources("ns:none"); err != ErrRelNotFound {
+		t.Error("waiting", ErrRelNotFound, "got", err)
+	}
+	r = loadResource(t, "example.json")
+	if _, err := r.AllEmbeddedResources(); err != ErrNoEmbeddedResources {
+		t.Error("waiting", ErrNoEmbeddedResources, "got", err)
+	}
 }
 
 func TestFindByRel(t *testing.T) {
@@ -37,7 +107,23 @@ func TestFindByRel(t *testing.T) {
 }
 
 func TestNewRessourcefromJson(t *testing.T) {
-
+	data := []struct {
+		title  string
+		in     string
+		outErr bool
+	}{
+		{"A", `{"name":"test"}`, false},
+		{"B", `{"_links":{"self":{"href":"/a"}},"_embedded":{"item":[{"id":1},{"id":2}]}}`, false},
+		{"C", `{"_links":"self"}`, true},
+		{"D", `{"_embedded":null}`, true},
+		{"E", `[1, 2]`, true},
+	}
+	for _, v := range data {
+		_, err := NewRessourcefromJson([]byte(v.in))
+		if (err != nil) != v.outErr {
+			t.Error("for", v.title, "waiting error", v.outErr, "got", err)
+		}
+	}
 }
 
 func TestExtractByRel(t *testing.T) {
@@ -47,3 +133,74 @@ func TestExtractByRel(t *testing.T) {
 func TestCopyMap(t *testing.T) {
 
 }
+
+func TestMarshalJSON(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatal("waiting testdata files got", err)
+	}
+	for _, f := range files {
+		in, _ := ioutil.ReadFile(f)
+		r, err := NewRessourcefromJson(in)
+		if err != nil {
+			t.Error("for", f, "waiting no error got", err)
+			continue
+		}
+		out, err := json.Marshal(r)
+		if err != nil {
+			t.Error("for", f, "waiting no error got", err)
+			continue
+		}
+		var a, b interface{}
+		json.Unmarshal(in, &a)
+		json.Unmarshal(out, &b)
+		if !reflect.DeepEqual(a, b) {
+			t.Error("for", f, "waiting", string(in), "got", string(out))
+		}
+	}
+}
+
+func TestAddLinks(t *testing.T) {
+	self, _ := NewLink("/orders", LinkOptionalParam{})
+	item, _ := NewLink("/orders/1", LinkOptionalParam{})
+	child := new(Resource)
+	child.SetProperty("id", 1)
+
+	r := new(Resource)
+	r.AddLink("self", *self)
+	r.AddLinks("item", *item)
+	r.AddEmbeddedResource("ex:order", child)
+	r.AddEmbeddedResources("ITEM", child)
+	r.AddEmbeddedResources("item", child)
+	r.SetProperty("total", 2)
+
+	want := `{"_links":{"item":[{"href":"/orders/1"}],"self":{"href":"/orders"}},` +
+		`"_embedded":{"ITEM":[{"id":1},{"id":1}],"ex:order":{"id":1}},"total":2}`
+	out, err := json.Marshal(r)
+	if err != nil || string(out) != want {
+		t.Error("waiting", want, "got", string(out), err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	r := loadResource(t, "exampleWithSubresource.json")
+	c := r.Clone()
+	c.RemoveLinks("NS:PARENT")
+	c.RemoveEmbeddedResources("ns:user")
+	c.SetProperty("name", "clone")
+	er, _ := r.EmbeddedResource("ns:user")
+	er.Clone().SetProperty("name", "other")
+
+	if _, err := r.Link("ns:parent"); err != nil {
+		t.Error("waiting the link to be kept in the original got", err)
+	}
+	if _, err := c.Link("ns:parent"); err != ErrRelNotFound {
+		t.Error("waiting", ErrRelNotFound, "got", err)
+	}
+	if _, err := c.EmbeddedResources("ns:user"); err != ErrRelNotFound {
+		t.Error("waiting", ErrRelNotFound, "got", err)
+	}
+	if _, ok := r.State()["name"]; ok || er.State()["name"] != "Example User" {
+		t.Error("waiting the original state to be kept got", r.State(), er.State())
+	}
+}
@@ -0,0 +1,356 @@
+package hal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+var (
+	ErrTemplateNotFound     = errors.New("Hal: Template not found")
+	errorTemplatesType      = errors.New("Hal: _templates must be a JSON object")
+	errorTemplateNoMethod   = errors.New("Hal: a template must have a method")
+	errorOptionsInvalidItem = errors.New("Hal: an option must be a string or an object")
+)
+
+// DefaultTemplate is the key of the template of a resource
+// holding a single one.
+const DefaultTemplate = "default"
+
+// The Template Object described in the HAL-FORMS specification:
+// an affordance telling how to send a request on the resource.
+// see https://rwcbook.github.io/hal-forms/#_the_code__templates_code_element
+type Template struct {
+	Title string `json:"title,omitempty"`
+
+	// REQUIRED
+	// The HTTP method of the request.
+	Method string `json:"method"`
+
+	// The media type of the body, application/json when empty.
+	ContentType string `json:"contentType,omitempty"`
+
+	// The URL the request is sent to, the self link of the resource
+	// when empty.
+	Target string `json:"target,omitempty"`
+
+	Properties []Property `json:"properties,omitempty"`
+}
+
+// The Property Object of a template: a field of the body.
+type Property struct {
+	// REQUIRED
+	Name string `json:"name"`
+
+	Prompt      string      `json:"prompt,omitempty"`
+	Placeholder string      `json:"placeholder,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	ReadOnly    bool        `json:"readOnly,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Templated   bool        `json:"templated,omitempty"`
+	Value       interface{} `json:"value,omitempty"`
+
+	// The regular expression the whole value must match.
+	Regex string `json:"regex,omitempty"`
+
+	// The bounds of a numeric value and of the length of a string.
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Step      *float64 `json:"step,omitempty"`
+
+	// The values the property may take, nil when it is free.
+	Options *Options `json:"options,omitempty"`
+}
+
+// The Options Object of a property: its values, inline or
+// returned by a link.
+type Options struct {
+	Inline []Option `json:"inline,omitempty"`
+	// The link to the values, a JSON array of strings
+	// or of objects like the inline ones.
+	Link *Link `json:"link,omitempty"`
+
+	// The members of the objects holding the prompt and the value,
+	// "prompt" and "value" when empty.
+	PromptField string `json:"promptField,omitempty"`
+	ValueField  string `json:"valueField,omitempty"`
+
+	MinItems       *int     `json:"minItems,omitempty"`
+	MaxItems       *int     `json:"maxItems,omitempty"`
+	SelectedValues []string `json:"selectedValues,omitempty"`
+}
+
+// Option is a value of a property with its prompt.
+type Option struct {
+	Prompt string `json:"prompt"`
+	Value  string `json:"value"`
+}
+
+// MarshalJSON returns the value alone when it is its own prompt,
+// as in the inline options given as strings.
+func (o Option) MarshalJSON() ([]byte, error) {
+	if o.Prompt == o.Value {
+		return json.Marshal(o.Value)
+	}
+	type option Option
+	return json.Marshal(option(o))
+}
+
+// UnmarshalJSON reads the options, the inline values being
+// strings or objects.
+func (o *Options) UnmarshalJSON(data []byte) error {
+	type options Options
+	var aux struct {
+		options
+		Inline json.RawMessage `json:"inline"`
+		Link   json.RawMessage `json:"link"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*o = Options(aux.options)
+	if len(aux.Link) > 0 && string(aux.Link) != "null" {
+		l, err := NewLinkFromJson(aux.Link)
+		if err != nil {
+			return err
+		}
+		o.Link = l
+	}
+	if len(aux.Inline) == 0 || string(aux.Inline) == "null" {
+		return nil
+	}
+	inline, err := o.ParseValues(aux.Inline)
+	if err != nil {
+		return err
+	}
+	o.Inline = inline
+	return nil
+}
+
+// ParseValues reads the JSON array of the values of the options,
+// as inlined or returned by the link of the options.
+// throws errorOptionsInvalidItem
+func (o *Options) ParseValues(data []byte) ([]Option, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("Hal: the options must be a JSON array : %v", err)
+	}
+	promptField, valueField := o.PromptField, o.ValueField
+	if promptField == "" {
+		promptField = "prompt"
+	}
+	if valueField == "" {
+		valueField = "value"
+	}
+	out := make([]Option, 0, len(items))
+	for _, item := range items {
+		var s string
+		if err := json.Unmarshal(item, &s); err == nil {
+			out = append(out, Option{Prompt: s, Value: s})
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(item, &m); err != nil || m == nil {
+			return nil, errorOptionsInvalidItem
+		}
+		opt := Option{Value: scalar(m[valueField])}
+		opt.Prompt = scalar(m[promptField])
+		if _, ok := m[promptField]; !ok {
+			opt.Prompt = opt.Value
+		}
+		out = append(out, opt)
+	}
+	return out, nil
+}
+
+// Templates returns the templates of the resource by key.
+func (r *Resource) Templates() map[string]*Template {
+	return r.templates
+}
+
+// Template returns the template of the given key,
+// "default" being the key of a single template.
+// throws ErrTemplateNotFound
+func (r *Resource) Template(key string) (*Template, error) {
+	if t, ok := r.templates[key]; ok {
+		return t, nil
+	}
+	return nil, ErrTemplateNotFound
+}
+
+// AddTemplate is adding the template of the given key,
+// replacing the one already set
+func (r *Resource) AddTemplate(key string, t *Template) {
+	if r.templates == nil {
+		r.templates = make(map[string]*Template)
+	}
+	r.templates[key] = t
+}
+
+// RemoveTemplate is removing the template of the given key
+func (r *Resource) RemoveTemplate(key string) {
+	delete(r.templates, key)
+}
+
+// extractTemplates fills the templates from the "_templates" member.
+func (r *Resource) extractTemplates(data []byte) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil || keys == nil {
+		return errorTemplatesType
+	}
+	for key, raw := range keys {
+		t := new(Template)
+		if err := json.Unmarshal(raw, t); err != nil {
+			return fmt.Errorf("Hal: the template %s is invalid : %v", key, err)
+		}
+		if t.Method == "" {
+			return errorTemplateNoMethod
+		}
+		r.AddTemplate(key, t)
+	}
+	return nil
+}
+
+// Property returns the property of the given name, or nil.
+func (t *Template) Property(name string) *Property {
+	for i := range t.Properties {
+		if t.Properties[i].Name == name {
+			return &t.Properties[i]
+		}
+	}
+	return nil
+}
+
+// Values returns the values filling the template: the given ones
+// and the values of the properties which are not given.
+func (t *Template) Values(values map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for _, p := range t.Properties {
+		if p.Value != nil && p.Value != "" {
+			out[p.Name] = p.Value
+		}
+	}
+	for k, v := range values {
+		out[k] = v
+	}
+	return out
+}
+
+// Validate checks the values against the properties of the template:
+// the required ones must be set, a value must match the regex, be
+// within the bounds and be one of the inline options if any.
+// return	[]string	The problems, e.g. "amount: is required", none when valid
+func (t *Template) Validate(values map[string]interface{}) []string {
+	var out []string
+	for _, p := range t.Properties {
+		for _, problem := range p.validate(values[p.Name]) {
+			out = append(out, p.Name+": "+problem)
+		}
+	}
+	return out
+}
+
+// validate returns the problems of the value of the property.
+func (p *Property) validate(v interface{}) []string {
+	if v == nil || v == "" {
+		if p.Required {
+			return []string{"is required"}
+		}
+		return nil
+	}
+	if p.ReadOnly && p.Value != nil && fmt.Sprint(v) != fmt.Sprint(p.Value) {
+		return []string{"is read-only"}
+	}
+	var out []string
+	if p.Options != nil {
+		out = append(out, p.Options.validate(v)...)
+		return out
+	}
+	s := scalar(v)
+	if p.Regex != "" {
+		re, err := regexp.Compile("^(?:" + p.Regex + ")$")
+		switch {
+		case err != nil:
+			out = append(out, "has an invalid regex "+p.Regex)
+		case !re.MatchString(s):
+			out = append(out, "must match "+p.Regex)
+		}
+	}
+	if p.Min != nil || p.Max != nil {
+		n, err := strconv.ParseFloat(s, 64)
+		switch {
+		case err != nil:
+			out = append(out, "must be a number")
+		case p.Min != nil && n < *p.Min:
+			out = append(out, "must be at least "+formatFloat(*p.Min))
+		case p.Max != nil && n > *p.Max:
+			out = append(out, "must be at most "+formatFloat(*p.Max))
+		}
+	}
+	if n := utf8.RuneCountInString(s); p.MinLength != nil && n < *p.MinLength {
+		out = append(out, "must have at least "+strconv.Itoa(*p.MinLength)+" characters")
+	} else if p.MaxLength != nil && n > *p.MaxLength {
+		out = append(out, "must have at most "+strconv.Itoa(*p.MaxLength)+" characters")
+	}
+	return out
+}
+
+// validate returns the problems of the value, or values,
+// selected among the options.
+func (o *Options) validate(v interface{}) []string {
+	selected, ok := v.([]interface{})
+	if !ok {
+		if ss, isStrings := v.([]string); isStrings {
+			for _, s := range ss {
+				selected = append(selected, s)
+			}
+		} else {
+			selected = []interface{}{v}
+		}
+	}
+	var out []string
+	if o.MinItems != nil && len(selected) < *o.MinItems {
+		out = append(out, "must have at least "+strconv.Itoa(*o.MinItems)+" values")
+	}
+	if o.MaxItems != nil && len(selected) > *o.MaxItems {
+		out = append(out, "must have at most "+strconv.Itoa(*o.MaxItems)+" values")
+	}
+	if len(o.Inline) == 0 {
+		return out
+	}
+	for _, s := range selected {
+		value, found := scalar(s), false
+		for _, opt := range o.Inline {
+			if opt.Value == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, value+" is not one of the options")
+		}
+	}
+	return out
+}
+
+// scalar returns the string representation of a JSON scalar.
+func scalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return formatFloat(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
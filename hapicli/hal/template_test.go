@@ -0,0 +1,123 @@
+package hal
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	r := loadResource(t, "exampleWithTemplates.json")
+	if len(r.Templates()) != 2 {
+		t.Fatal("waiting 2 templates got", r.Templates())
+	}
+	if _, err := r.Template("unknown"); err != ErrTemplateNotFound {
+		t.Error("waiting", ErrTemplateNotFound, "got", err)
+	}
+	d, err := r.Template(DefaultTemplate)
+	if err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if d.Method != "PUT" || d.Title != "Update the order" || len(d.Properties) != 6 {
+		t.Error("waiting the default template got", d)
+	}
+	if p := d.Property("amount"); p == nil || *p.Min != 1 || *p.Max != 1000 {
+		t.Error("waiting the bounds of amount got", p)
+	}
+	if p := d.Property("shipping"); p == nil || !reflect.DeepEqual(p.Options.Inline, []Option{{"standard", "standard"}, {"express", "express"}}) {
+		t.Error("waiting the inline options of shipping got", p)
+	}
+	if p := d.Property("country"); p == nil || p.Options.Link == nil || p.Options.Link.Href() != "https://example.com/api/countries" {
+		t.Error("waiting the options link of country got", p)
+	}
+	if d.Property("unknown") != nil {
+		t.Error("waiting no property")
+	}
+	if del, _ := r.Template("delete"); del == nil || del.Target != "https://example.com/api/orders/1/cancel" {
+		t.Error("waiting the target of delete got", del)
+	}
+
+	// round trip
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	again, err := NewRessourcefromJson(b)
+	if err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if !reflect.DeepEqual(again.Templates(), r.Templates()) {
+		t.Error("waiting the same templates got", string(b))
+	}
+	if c := r.Clone(); !reflect.DeepEqual(c.Templates(), r.Templates()) {
+		t.Error("waiting the templates to be cloned")
+	}
+
+	for _, in := range []string{`{"_templates":[]}`, `{"_templates":{"default":{}}}`, `{"_templates":{"default":{"method":"GET","properties":[{"name":"a","options":{"inline":[1]}}]}}}`} {
+		if _, err := NewRessourcefromJson([]byte(in)); err == nil {
+			t.Error("for", in, "waiting an error")
+		}
+	}
+	if _, err := NewBuilder().Template(DefaultTemplate, &Template{}).Build(); err == nil {
+		t.Error("waiting an error for a template without method")
+	}
+}
+
+func TestOptionsParseValues(t *testing.T) {
+	data := []struct {
+		title   string
+		options Options
+		in      string
+		out     []Option
+		err     bool
+	}{
+		{"A", Options{}, `["a","b"]`, []Option{{"a", "a"}, {"b", "b"}}, false},
+		{"B", Options{}, `[{"prompt":"France","value":"FR"},{"value":1}]`, []Option{{"France", "FR"}, {"1", "1"}}, false},
+		{"C", Options{PromptField: "name", ValueField: "code"}, `[{"name":"France","code":"FR"}]`, []Option{{"France", "FR"}}, false},
+		{"D", Options{}, `{}`, nil, true},
+		{"E", Options{}, `[true]`, nil, true},
+	}
+	for _, v := range data {
+		out, err := v.options.ParseValues([]byte(v.in))
+		if (err != nil) != v.err {
+			t.Error("for", v.title, "waiting an error", v.err, "got", err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(out, v.out) {
+			t.Error("for", v.title, "waiting", v.out, "got", out)
+		}
+	}
+}
+
+func TestTemplateValidate(t *testing.T) {
+	r := loadResource(t, "exampleWithTemplates.json")
+	d, _ := r.Template(DefaultTemplate)
+	data := []struct {
+		title string
+		in    map[string]interface{}
+		out   []string
+	}{
+		{"A", map[string]interface{}{"reference": "ORD-2", "amount": 10.5, "label": "gift", "shipping": "express"}, nil},
+		{"B", map[string]interface{}{}, []string{"reference: is required"}},
+		{"C", map[string]interface{}{"reference": "ord-2x", "amount": "ten", "label": "a very long label"}, []string{
+			"reference: must match [A-Z]{3}-[0-9]+",
+			"amount: must be a number",
+			"label: must have at most 10 characters",
+		}},
+		{"D", map[string]interface{}{"reference": "ORD-2", "amount": 0, "status": "closed"}, []string{
+			"amount: must be at least 1",
+			"status: is read-only",
+		}},
+		{"E", map[string]interface{}{"reference": "ORD-2", "amount": "2000", "shipping": []interface{}{"standard", "drone"}}, []string{
+			"amount: must be at most 1000",
+			"shipping: must have at most 1 values",
+			"shipping: drone is not one of the options",
+		}},
+		{"F", d.Values(map[string]interface{}{"amount": 5}), nil},
+	}
+	for _, v := range data {
+		if out := d.Validate(v.in); !reflect.DeepEqual(out, v.out) {
+			t.Error("for", v.title, "waiting", v.out, "got", out)
+		}
+	}
+}
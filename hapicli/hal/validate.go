@@ -0,0 +1,348 @@
+package hal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity is the level of a Violation.
+type Severity int
+
+const (
+	// SeverityError is breaking a MUST of the specification,
+	// the document is not valid HAL.
+	SeverityError Severity = iota
+	// SeverityWarning is breaking a SHOULD of the specification.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Violation is a part of a document not following the
+// JSON Hypertext Application Language (draft-kelly-json-hal-07).
+type Violation struct {
+	// The JSON Pointer (RFC6901) of the faulty value.
+	Pointer  string
+	Severity Severity
+	Message  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %q: %s", v.Severity, v.Pointer, v.Message)
+}
+
+// ValidationError is returned by a strict Decoder
+// for a document holding violations of severity error.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "Hal: invalid document: " + strings.Join(msgs, "; ")
+}
+
+// linkStringProperties are the optional properties of a Link Object
+// whose value must be a string.
+var linkStringProperties = []string{"type", "deprecation", "name", "profile", "title", "hreflang"}
+
+// Validate reports all the violations of draft-kelly-json-hal-07 found
+// in the document, in the order of the document with sorted keys.
+// An empty result means the document is valid HAL.
+// see https://tools.ietf.org/html/draft-kelly-json-hal-07
+func Validate(data []byte) []Violation {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return []Violation{{"", SeverityError, fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	var v validator
+	v.resource("", doc, nil)
+	return v.violations
+}
+
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) report(ptr string, s Severity, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{ptr, s, fmt.Sprintf(format, args...)})
+}
+
+// resource validates a Resource Object, curies are the names of the
+// CURIEs declared by the resource or by its ancestors.
+func (v *validator) resource(ptr string, doc interface{}, curies map[string]bool) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		v.report(ptr, SeverityError, "a resource must be a JSON object")
+		return
+	}
+	if ls, ok := obj["_links"]; ok {
+		curies = v.links(ptr+"/_links", ls, curies)
+	}
+	if ers, ok := obj["_embedded"]; ok {
+		v.embedded(ptr+"/_embedded", ers, curies)
+	}
+	if ts, ok := obj["_templates"]; ok {
+		v.templates(ptr+"/_templates", ts)
+	}
+}
+
+// templates validates the HAL-FORMS "_templates" member.
+// see https://rwcbook.github.io/hal-forms/
+func (v *validator) templates(ptr string, doc interface{}) {
+	keys, ok := doc.(map[string]interface{})
+	if !ok {
+		v.report(ptr, SeverityError, "_templates must be a JSON object")
+		return
+	}
+	if _, ok := keys[DefaultTemplate]; !ok && len(keys) == 1 {
+		v.report(ptr, SeverityWarning, "a single template should have the key %s", DefaultTemplate)
+	}
+	for _, key := range sortedKeys(keys) {
+		tPtr := ptr + "/" + escapePointer(key)
+		t, ok := keys[key].(map[string]interface{})
+		if !ok {
+			v.report(tPtr, SeverityError, "a template must be a JSON object")
+			continue
+		}
+		if _, isString := t["method"].(string); !isString {
+			v.report(tPtr+"/method", SeverityError, "method is required and must be a string")
+		}
+		ps, ok := t["properties"]
+		if !ok {
+			continue
+		}
+		props, ok := ps.([]interface{})
+		if !ok {
+			v.report(tPtr+"/properties", SeverityError, "properties must be a JSON array")
+			continue
+		}
+		for i, p := range props {
+			pPtr := fmt.Sprintf("%s/properties/%d", tPtr, i)
+			prop, ok := p.(map[string]interface{})
+			if !ok {
+				v.report(pPtr, SeverityError, "a property must be a JSON object")
+				continue
+			}
+			if _, isString := prop["name"].(string); !isString {
+				v.report(pPtr+"/name", SeverityError, "name is required and must be a string")
+			}
+		}
+	}
+}
+
+// links validates the "_links" member and returns the CURIE
+// names in scope for the resource.
+func (v *validator) links(ptr string, doc interface{}, inherited map[string]bool) map[string]bool {
+	rels, ok := doc.(map[string]interface{})
+	if !ok {
+		v.report(ptr, SeverityError, "_links must be a JSON object")
+		return inherited
+	}
+	curies := make(map[string]bool)
+	for name := range inherited {
+		curies[name] = true
+	}
+	if cs, ok := rels["curies"]; ok {
+		v.curies(ptr+"/curies", cs, curies)
+	}
+	for _, rel := range sortedKeys(rels) {
+		if rel == "curies" {
+			continue
+		}
+		relPtr := ptr + "/" + escapePointer(rel)
+		if prefix := curiePrefix(rel); prefix != "" && !curies[prefix] {
+			v.report(relPtr, SeverityWarning, "the CURIE prefix %q is not declared", prefix)
+		}
+		switch ls := rels[rel].(type) {
+		case map[string]interface{}:
+			v.link(relPtr, ls)
+		case []interface{}:
+			for i, l := range ls {
+				v.link(fmt.Sprintf("%s/%d", relPtr, i), l)
+			}
+		default:
+			v.report(relPtr, SeverityError, "a rel must hold a link object or an array of link objects")
+		}
+	}
+	return curies
+}
+
+// curies validates the reserved "curies" rel and adds the names it declares.
+func (v *validator) curies(ptr string, doc interface{}, names map[string]bool) {
+	cs, ok := doc.([]interface{})
+	if !ok {
+		v.report(ptr, SeverityError, "curies must be an array of link objects")
+		return
+	}
+	for i, c := range cs {
+		cPtr := fmt.Sprintf("%s/%d", ptr, i)
+		if !v.link(cPtr, c) {
+			continue
+		}
+		l := c.(map[string]interface{})
+		if name, ok := l["name"].(string); !ok || name == "" {
+			v.report(cPtr, SeverityError, "a curie must have a name")
+		} else {
+			names[name] = true
+		}
+		if href, ok := l["href"].(string); ok && !strings.Contains(href, "{rel}") {
+			v.report(cPtr+"/href", SeverityError, "the href of a curie must be a URI template with a {rel} variable")
+		}
+		if l["templated"] != true {
+			v.report(cPtr+"/templated", SeverityWarning, "a curie should be templated")
+		}
+	}
+}
+
+// link validates a Link Object, it returns false if it is not an object.
+func (v *validator) link(ptr string, doc interface{}) bool {
+	l, ok := doc.(map[string]interface{})
+	if !ok {
+		v.report(ptr, SeverityError, "a link must be a JSON object")
+		return false
+	}
+	href, isString := l["href"].(string)
+	if _, ok := l["href"]; !ok {
+		v.report(ptr, SeverityError, "href is required")
+	} else if !isString {
+		v.report(ptr+"/href", SeverityError, "href must be a string")
+	}
+	templated, ok := l["templated"]
+	if _, isBool := templated.(bool); ok && !isBool {
+		v.report(ptr+"/templated", SeverityError, "templated must be a boolean")
+	}
+	isTemplate := strings.Contains(href, "{") && strings.Contains(href, "}")
+	switch {
+	case templated == true && !isTemplate:
+		v.report(ptr+"/templated", SeverityWarning, "templated is true but href is not a URI template")
+	case templated != true && isTemplate:
+		v.report(ptr+"/href", SeverityWarning, "href is a URI template but templated is not true")
+	}
+	for _, p := range linkStringProperties {
+		if pv, ok := l[p]; ok {
+			if _, isString := pv.(string); !isString {
+				v.report(ptr+"/"+p, SeverityError, "%s must be a string", p)
+			}
+		}
+	}
+	return true
+}
+
+// embedded validates the "_embedded" member and its resources.
+func (v *validator) embedded(ptr string, doc interface{}, curies map[string]bool) {
+	rels, ok := doc.(map[string]interface{})
+	if !ok {
+		v.report(ptr, SeverityError, "_embedded must be a JSON object")
+		return
+	}
+	for _, rel := range sortedKeys(rels) {
+		relPtr := ptr + "/" + escapePointer(rel)
+		if rel == "curies" {
+			v.report(relPtr, SeverityWarning, "curies is reserved to _links")
+		}
+		switch ers := rels[rel].(type) {
+		case map[string]interface{}:
+			v.resource(relPtr, ers, curies)
+		case []interface{}:
+			for i, er := range ers {
+				v.resource(fmt.Sprintf("%s/%d", relPtr, i), er, curies)
+			}
+		default:
+			v.report(relPtr, SeverityError, "an embedded rel must hold a resource object or an array of resource objects")
+		}
+	}
+}
+
+// curiePrefix returns the prefix of a CURIE rel, or an empty
+// string for a registered rel or an absolute URI.
+func curiePrefix(rel string) string {
+	i := strings.Index(rel, ":")
+	if i <= 0 || strings.HasPrefix(rel[i:], "://") {
+		return ""
+	}
+	switch strings.ToLower(rel[:i]) {
+	case "http", "https", "urn", "tag", "mailto":
+		return ""
+	}
+	return rel[:i]
+}
+
+// escapePointer escapes a JSON Pointer reference token.
+// see https://tools.ietf.org/html/rfc6901#section-3
+func escapePointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Decoder builds resources from their JSON representation.
+// In strict mode, the documents holding violations of
+// severity error are rejected with a *ValidationError.
+type Decoder struct {
+	strict bool
+}
+
+// NewDecoder create a Decoder, which is not strict.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// SetStrict is making the decoder reject the invalid documents
+func (d *Decoder) SetStrict(b bool) {
+	d.strict = b
+}
+
+// Decode builds the resource of the document.
+// throws *ValidationError
+func (d *Decoder) Decode(data []byte) (*Resource, error) {
+	if err := d.check(data); err != nil {
+		return nil, err
+	}
+	return NewRessourcefromJson(data)
+}
+
+// Unmarshal stores the document in the struct pointed to by v, see Unmarshal.
+// throws *ValidationError
+func (d *Decoder) Unmarshal(data []byte, v interface{}) error {
+	if err := d.check(data); err != nil {
+		return err
+	}
+	return Unmarshal(data, v)
+}
+
+func (d *Decoder) check(data []byte) error {
+	if !d.strict {
+		return nil
+	}
+	var errs []Violation
+	for _, v := range Validate(data) {
+		if v.Severity == SeverityError {
+			errs = append(errs, v)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{errs}
+	}
+	return nil
+}
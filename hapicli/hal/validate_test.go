@@ -0,0 +1,96 @@
+package hal
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	data := []struct {
+		title string
+		in    string
+		out   []Violation
+	}{
+		{"A", `{"_links":{"self":{"href":"/orders/1"}},"amount":1}`, nil},
+		{"B", `[]`, []Violation{{"", SeverityError, "a resource must be a JSON object"}}},
+		{"C", `{"_links":{"self":{"title":"no href"}}}`, []Violation{{"/_links/self", SeverityError, "href is required"}}},
+		{"D", `{"_links":{"search":{"href":"/orders{?q}"}}}`, []Violation{{"/_links/search/href", SeverityWarning, "href is a URI template but templated is not true"}}},
+		{"E", `{"_links":{"search":{"href":"/orders","templated":true}}}`, []Violation{{"/_links/search/templated", SeverityWarning, "templated is true but href is not a URI template"}}},
+		{"F", `{"_links":{"item":[{"href":"/a"},{"href":1,"title":2}]}}`, []Violation{
+			{"/_links/item/1/href", SeverityError, "href must be a string"},
+			{"/_links/item/1/title", SeverityError, "title must be a string"},
+		}},
+		{"G", `{"_links":"self","_embedded":[]}`, []Violation{
+			{"/_links", SeverityError, "_links must be a JSON object"},
+			{"/_embedded", SeverityError, "_embedded must be a JSON object"},
+		}},
+		{"H", `{"_embedded":{"items":[{"_links":{"self":{}}},"x"],"a/b":1}}`, []Violation{
+			{"/_embedded/a~1b", SeverityError, "an embedded rel must hold a resource object or an array of resource objects"},
+			{"/_embedded/items/0/_links/self", SeverityError, "href is required"},
+			{"/_embedded/items/1", SeverityError, "a resource must be a JSON object"},
+		}},
+		{"I", `{"_links":{"curies":{"name":"ex","href":"/rels/{rel}","templated":true}}}`, []Violation{{"/_links/curies", SeverityError, "curies must be an array of link objects"}}},
+		{"J", `{"_links":{"curies":[{"href":"/rels/x"}],"ex:a":{"href":"/a"}}}`, []Violation{
+			{"/_links/curies/0", SeverityError, "a curie must have a name"},
+			{"/_links/curies/0/href", SeverityError, "the href of a curie must be a URI template with a {rel} variable"},
+			{"/_links/curies/0/templated", SeverityWarning, "a curie should be templated"},
+			{"/_links/ex:a", SeverityWarning, `the CURIE prefix "ex" is not declared`},
+		}},
+		{"K", `{"_links":{"curies":[{"name":"ex","href":"/rels/{rel}","templated":true}]},"_embedded":{"ex:a":{"_links":{"ex:b":{"href":"/b"},"http://example.com/rels/c":{"href":"/c"}}}}}`, nil},
+		{"L", `{"_embedded":{"curies":{}}}`, []Violation{{"/_embedded/curies", SeverityWarning, "curies is reserved to _links"}}},
+		{"M", `{"_templates":{"create":{"method":"POST","properties":[{"name":"a"}]}}}`, []Violation{{"/_templates", SeverityWarning, "a single template should have the key default"}}},
+		{"N", `{"_templates":{"default":{"properties":[{"prompt":"x"},1]},"a":"b","c":{"method":"PUT","properties":{}}}}`, []Violation{
+			{"/_templates/a", SeverityError, "a template must be a JSON object"},
+			{"/_templates/c/properties", SeverityError, "properties must be a JSON array"},
+			{"/_templates/default/method", SeverityError, "method is required and must be a string"},
+			{"/_templates/default/properties/0/name", SeverityError, "name is required and must be a string"},
+			{"/_templates/default/properties/1", SeverityError, "a property must be a JSON object"},
+		}},
+		{"O", `{"_templates":[]}`, []Violation{{"/_templates", SeverityError, "_templates must be a JSON object"}}},
+	}
+	for _, v := range data {
+		out := Validate([]byte(v.in))
+		if !reflect.DeepEqual(out, v.out) {
+			t.Error("for", v.title, "waiting", v.out, "got", out)
+		}
+	}
+	if out := Validate([]byte(`{`)); len(out) != 1 || out[0].Pointer != "" || out[0].Severity != SeverityError {
+		t.Error("waiting an invalid JSON violation got", out)
+	}
+	files, _ := filepath.Glob("testdata/*.json")
+	for _, f := range files {
+		b, _ := ioutil.ReadFile(f)
+		if out := Validate(b); len(out) != 0 {
+			t.Error("for", f, "waiting no violation got", out)
+		}
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	data := []struct {
+		title  string
+		strict bool
+		in     string
+		outErr bool
+	}{
+		{"A", false, `{"_links":{"self":{"title":"no href"}}}`, false},
+		{"B", true, `{"_links":{"self":{"title":"no href"}}}`, true},
+		{"C", true, `{"_links":{"search":{"href":"/orders{?q}"}}}`, false},
+		{"D", true, `{"_links":{"self":{"href":"/orders/1"}}}`, false},
+	}
+	for _, v := range data {
+		d := NewDecoder()
+		d.SetStrict(v.strict)
+		_, err := d.Decode([]byte(v.in))
+		if _, ok := err.(*ValidationError); ok != v.outErr {
+			t.Error("for", v.title, "waiting a validation error", v.outErr, "got", err)
+		}
+		var o testOrder
+		err = d.Unmarshal([]byte(v.in), &o)
+		if _, ok := err.(*ValidationError); ok != v.outErr {
+			t.Error("for", v.title, "waiting a validation error", v.outErr, "got", err)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package hal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MediaTypeXML is the content type of the XML representation of HAL.
+const MediaTypeXML = "application/hal+xml"
+
+var errorXMLRoot = errors.New("Hal: the XML document must be a resource element")
+
+// NewResourceFromXML builds a Resource from its XML representation
+// described in draft-michaud-xml-hal-01:
+//
+//	<resource href="/orders" xmlns:ex="http://example.com/rels/">
+//		<link rel="ex:search" href="/orders{?id}" templated="true"/>
+//		<resource rel="ex:order" href="/orders/123">
+//			<total>30.00</total>
+//		</resource>
+//		<currentlyProcessing>14</currentlyProcessing>
+//	</resource>
+//
+// The href of a resource is its self link and its xmlns:name attributes
+// are its CURIEs. An element holding text is a string property, an element
+// holding elements is an object and repeated elements are an array.
+// see https://tools.ietf.org/html/draft-michaud-xml-hal-01
+func NewResourceFromXML(data []byte) (*Resource, error) {
+	r := new(Resource)
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(r); err != nil {
+		if err == errorXMLRoot {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Hal: invalid XML : %v", err)
+	}
+	return r, nil
+}
+
+// UnmarshalXML reads the resource element, see NewResourceFromXML.
+func (r *Resource) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if start.Name.Local != "resource" {
+		return errorXMLRoot
+	}
+	r.init()
+	for _, a := range start.Attr {
+		switch {
+		case a.Name.Space == "" && a.Name.Local == "href":
+			self, err := NewLink(a.Value, LinkOptionalParam{})
+			if err != nil {
+				return err
+			}
+			r.AddLink(SELF.Name(), *self)
+		case a.Name.Space == "xmlns":
+			curie, err := NewLink(a.Value+"{rel}", LinkOptionalParam{Name: a.Name.Local, Templated: true})
+			if err != nil {
+				return err
+			}
+			r.AddLinks(CURIES.Name(), *curie)
+		}
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "link":
+				rel, l, err := xmlLink(t)
+				if err != nil {
+					return err
+				}
+				if rel == CURIES.Name() {
+					r.AddLinks(rel, *l)
+				} else {
+					r.AddLink(rel, *l)
+				}
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case "resource":
+				er := new(Resource)
+				if err := er.UnmarshalXML(d, t); err != nil {
+					return err
+				}
+				r.AddEmbeddedResource(xmlAttr(t, "rel"), er)
+			default:
+				v, err := xmlValue(d)
+				if err != nil {
+					return err
+				}
+				addXMLValue(r.state, t.Name.Local, v)
+			}
+		}
+	}
+}
+
+// xmlLink returns the rel and the link of a link element.
+func xmlLink(t xml.StartElement) (string, *Link, error) {
+	l, err := NewLink(xmlAttr(t, "href"), LinkOptionalParam{
+		Templated:   xmlAttr(t, "templated") == "true",
+		MediaType:   xmlAttr(t, "type"),
+		Deprecation: xmlAttr(t, "deprecation"),
+		Name:        xmlAttr(t, "name"),
+		Profile:     xmlAttr(t, "profile"),
+		Title:       xmlAttr(t, "title"),
+		Hreflang:    xmlAttr(t, "hreflang"),
+	})
+	return xmlAttr(t, "rel"), l, err
+}
+
+// xmlAttr returns the value of the attribute of the element, or "".
+func xmlAttr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// xmlValue reads the value of a property element: its text,
+// or an object when it holds elements.
+func xmlValue(d *xml.Decoder) (interface{}, error) {
+	var (
+		text bytes.Buffer
+		obj  map[string]interface{}
+	)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			v, err := xmlValue(d)
+			if err != nil {
+				return nil, err
+			}
+			if obj == nil {
+				obj = make(map[string]interface{})
+			}
+			addXMLValue(obj, t.Name.Local, v)
+		case xml.EndElement:
+			if obj != nil {
+				return obj, nil
+			}
+			return strings.TrimSpace(text.String()), nil
+		}
+	}
+}
+
+// addXMLValue sets the value of the member, making an array
+// of the values of a repeated element.
+func addXMLValue(m map[string]interface{}, name string, v interface{}) {
+	prev, ok := m[name]
+	if !ok {
+		m[name] = v
+		return
+	}
+	if arr, isArray := prev.([]interface{}); isArray {
+		m[name] = append(arr, v)
+		return
+	}
+	m[name] = []interface{}{prev, v}
+}
+
+// MarshalXML writes the resource element of the XML representation of HAL,
+// see NewResourceFromXML. The links, the embedded resources and the
+// properties are sorted, the templates are not represented.
+// The name of a property must be an XML name without colon, other than
+// link and resource which are reserved to HAL.
+func (r *Resource) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "resource"}
+	var links []xml.StartElement
+	for _, rel := range sortedRels(linkRels(r.links)) {
+		ls := r.links[rel]
+		for _, l := range ls {
+			switch {
+			case rel == SELF.Name() && len(ls) == 1:
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "href"}, Value: l.Href()})
+			case rel == CURIES.Name() && l.Name() != "" && strings.HasSuffix(l.Href(), "{rel}"):
+				start.Attr = append(start.Attr, xml.Attr{
+					Name:  xml.Name{Local: "xmlns:" + l.Name()},
+					Value: strings.TrimSuffix(l.Href(), "{rel}"),
+				})
+			default:
+				links = append(links, linkElement(rel, l))
+			}
+		}
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, l := range links {
+		if err := e.EncodeToken(l); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(l.End()); err != nil {
+			return err
+		}
+	}
+	for _, rel := range sortedRels(embeddedRels(r.embeddedResources)) {
+		for _, er := range r.embeddedResources[rel] {
+			child := xml.StartElement{Attr: []xml.Attr{{Name: xml.Name{Local: "rel"}, Value: rel}}}
+			if err := er.MarshalXML(e, child); err != nil {
+				return err
+			}
+		}
+	}
+	keys := make([]string, 0, len(r.state))
+	for k := range r.state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "link" || k == "resource" {
+			return fmt.Errorf("Hal: the property %s is reserved in XML", k)
+		}
+		if err := encodeXMLValue(e, k, r.state[k]); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// linkElement returns the link element of the link.
+func linkElement(rel string, l Link) xml.StartElement {
+	t := xml.StartElement{Name: xml.Name{Local: "link"}}
+	attr := func(name, value string) {
+		if value != "" {
+			t.Attr = append(t.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+		}
+	}
+	attr("rel", rel)
+	attr("href", l.href)
+	if l.templated {
+		attr("templated", "true")
+	}
+	attr("type", l.mediaType)
+	attr("deprecation", l.deprecation)
+	attr("name", l.name)
+	attr("profile", l.profile)
+	attr("title", l.title)
+	attr("hreflang", l.hreflang)
+	return t
+}
+
+// encodeXMLValue writes the element of a property: an element per
+// value of an array and an element per member of an object.
+func encodeXMLValue(e *xml.Encoder, name string, v interface{}) error {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if err := encodeXMLValue(e, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []string:
+		for _, item := range v {
+			if err := encodeXMLValue(e, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !isNCName(name) {
+		return fmt.Errorf("Hal: the property %q is not a valid XML element name", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	var err error
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err = encodeXMLValue(e, k, v[k]); err != nil {
+				return err
+			}
+		}
+	case string:
+		err = e.EncodeToken(xml.CharData(v))
+	case float64:
+		err = e.EncodeToken(xml.CharData(strconv.FormatFloat(v, 'f', -1, 64)))
+	default:
+		err = e.EncodeToken(xml.CharData(fmt.Sprint(v)))
+	}
+	if err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// isNCName tells if the name is an XML name without colon.
+// see https://www.w3.org/TR/xml-names/#NT-NCName
+func isNCName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_' || unicode.IsLetter(c):
+		case i > 0 && (c == '-' || c == '.' || unicode.IsDigit(c) || unicode.Is(unicode.Mn, c)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sortedRels returns the rels sorted.
+func sortedRels(rels []string) []string {
+	sort.Strings(rels)
+	return rels
+}
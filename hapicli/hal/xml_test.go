@@ -0,0 +1,129 @@
+package hal
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewResourceFromXML(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/example.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewResourceFromXML(data)
+	if err != nil {
+		t.Fatal("waiting no error got", err)
+	}
+	if self, _ := r.Link("self"); self == nil || self.Href() != "/orders" {
+		t.Error("waiting the self link got", self)
+	}
+	if search, _ := r.Link("ex:search"); search == nil || !search.Templated() {
+		t.Error("waiting the templated search link got", search)
+	}
+	if curies, _ := r.Links("curies"); len(curies) != 1 || curies[0].Name() != "ex" || curies[0].Href() != "http://example.org/rels/{rel}" {
+		t.Error("waiting the ex curie got", curies)
+	}
+	if r.State()["currentlyProcessing"] != "14" {
+		t.Error("waiting the properties got", r.State())
+	}
+	orders, err := r.EmbeddedResources("ex:order")
+	if err != nil || len(orders) != 2 {
+		t.Fatal("waiting 2 orders got", orders, err)
+	}
+	if c, _ := orders[0].Link("ex:customer"); c == nil || c.Title() != "Bob Jones <bob@jones.com>" {
+		t.Error("waiting the customer link got", c)
+	}
+	basket, _ := orders[0].EmbeddedResource("ex:basket")
+	items, ok := basket.State()["item"].([]interface{})
+	if !ok || len(items) != 2 || items[1].(map[string]interface{})["sku"] != "GFZ111" {
+		t.Error("waiting 2 items got", basket.State())
+	}
+	basket, _ = orders[1].EmbeddedResource("ex:basket")
+	if item, ok := basket.State()["item"].(map[string]interface{}); !ok || item["price"] != "9.00" {
+		t.Error("waiting 1 item got", basket.State())
+	}
+
+	if _, err := NewResourceFromXML([]byte(`<links/>`)); err != errorXMLRoot {
+		t.Error("waiting", errorXMLRoot, "got", err)
+	}
+	if _, err := NewResourceFromXML([]byte(`<resource>`)); err == nil || !strings.HasPrefix(err.Error(), "Hal: invalid XML") {
+		t.Error("waiting an invalid XML error got", err)
+	}
+	for _, in := range []string{`<links/>`, `<resource><link rel="a" href=""/></resource>`, `<resource>`} {
+		if _, err := NewResourceFromXML([]byte(in)); err == nil {
+			t.Error("for", in, "waiting an error")
+		}
+	}
+}
+
+func TestMarshalXML(t *testing.T) {
+	data := []string{
+		"testdata/example.xml",
+		"testdata/exampleWithSubresource.json",
+		"testdata/exampleWithTemplate.json",
+		"testdata/exampleWithArray.json",
+	}
+	for _, f := range data {
+		in, _ := ioutil.ReadFile(f)
+		var (
+			r   *Resource
+			err error
+		)
+		if f[len(f)-4:] == ".xml" {
+			r, err = NewResourceFromXML(in)
+		} else {
+			r, err = NewRessourcefromJson(in)
+		}
+		if err != nil {
+			t.Error("for", f, "waiting no error got", err)
+			continue
+		}
+		out, err := xml.MarshalIndent(r, "", "  ")
+		if err != nil {
+			t.Error("for", f, "waiting no error got", err)
+			continue
+		}
+		again, err := NewResourceFromXML(out)
+		if err != nil {
+			t.Error("for", f, "waiting no error got", err, string(out))
+			continue
+		}
+		// the XML properties are strings, compare the XML representations
+		out2, _ := xml.MarshalIndent(again, "", "  ")
+		if string(out) != string(out2) {
+			t.Error("for", f, "waiting", string(out), "got", string(out2))
+		}
+		if f[len(f)-4:] == ".xml" {
+			a, _ := json.Marshal(r)
+			b, _ := json.Marshal(again)
+			if !reflect.DeepEqual(a, b) {
+				t.Error("for", f, "waiting", string(a), "got", string(b))
+			}
+		}
+	}
+
+	invalid := []*Resource{
+		NewBuilder().Prop("first name", "x").MustBuild(),
+		NewBuilder().Prop("1st", "x").MustBuild(),
+		NewBuilder().Prop("ex:name", "x").MustBuild(),
+		NewBuilder().Prop("a", map[string]interface{}{"b c": 1}).MustBuild(),
+		NewBuilder().Prop("link", map[string]interface{}{"href": "/evil"}).MustBuild(),
+		NewBuilder().Prop("resource", "x").MustBuild(),
+	}
+	for i, r := range invalid {
+		if out, err := xml.Marshal(r); err == nil {
+			t.Error("for", i, "waiting an error got", string(out))
+		}
+	}
+
+	r := NewBuilder().Self("/a").Prop("n", 1.5).Prop("ok", true).Prop("none", nil).Prop("tags", []string{"x", "y"}).MustBuild()
+	out, _ := xml.Marshal(r)
+	expected := `<resource href="/a"><n>1.5</n><none></none><ok>true</ok><tags>x</tags><tags>y</tags></resource>`
+	if string(out) != expected {
+		t.Error("waiting", expected, "got", string(out))
+	}
+}
@@ -0,0 +1,263 @@
+// Package halserver renders the hal resources from the
+// http.Handler of a Go server.
+//
+//	rd := halserver.NewRenderer()
+//	rd.AddCurie("ex", "https://api.example.com/rels/{rel}")
+//	rd.SetEmbedParam("embed")
+//
+//	func (h *orders) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		order := hal.NewBuilder().Prop("amount", 100).MustBuild()
+//		rd.Render(w, r, http.StatusOK, order)
+//	}
+package halserver
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// The media types rendered.
+const (
+	MediaTypeHAL  = "application/hal+json"
+	MediaTypeJSON = "application/json"
+)
+
+var (
+	ErrNotAcceptable = errors.New("Halserver: the client accepts neither application/hal+json nor application/json")
+)
+
+// Renderer renders the resources of a service.
+type Renderer struct {
+	// The CURIEs of the service, added to the resources using them.
+	curies []hal.Link
+
+	// The query parameter listing the embedded rels to keep,
+	// the embedding is not controlled when empty.
+	embedParam string
+}
+
+// NewRenderer create a Renderer
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// AddCurie is registering a CURIE of the service: a templated href
+// documenting the rels prefixed by name, e.g. "https://example.com/rels/{rel}".
+func (rd *Renderer) AddCurie(name, href string) error {
+	if !strings.Contains(href, "{rel}") {
+		return errors.New("Halserver: the href of a CURIE must contain {rel}")
+	}
+	l, err := hal.NewLink(href, hal.LinkOptionalParam{Templated: true, Name: name})
+	if err != nil {
+		return err
+	}
+	rd.curies = append(rd.curies, *l)
+	return nil
+}
+
+// SetEmbedParam is setting the query parameter controlling the embedding,
+// e.g. "embed": with ?embed=rel1,rel2 only the resources of these rels
+// are embedded, the others being replaced by links to their self href.
+func (rd *Renderer) SetEmbedParam(p string) {
+	rd.embedParam = p
+}
+
+// Render writes the resource with the given status code.
+//
+// The media type is negotiated with the Accept header of the request,
+// application/hal+json being preferred. The self links are made absolute
+// from the request URL, a self link to the request URL is added when the
+// resource has none, and the registered CURIEs used by the resource
+// are added to its links. The resource given is not modified.
+//
+// ErrNotAcceptable is returned after writing a 406 Not Acceptable
+// when the client accepts none of the media types.
+func (rd *Renderer) Render(w http.ResponseWriter, req *http.Request, status int, r *hal.Resource) error {
+	mt := negotiate(req.Header.Get("Accept"))
+	if mt == "" {
+		http.Error(w, ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+
+	base := requestURL(req)
+	r = r.Clone()
+	if _, err := r.Links(hal.SELF.Name()); err != nil {
+		self, _ := hal.NewLink(base.String(), hal.LinkOptionalParam{})
+		r.AddLink(hal.SELF.Name(), *self)
+	}
+	absoluteSelf(r, base)
+	if rd.embedParam != "" {
+		if v, ok := req.URL.Query()[rd.embedParam]; ok {
+			rd.filterEmbedded(r, strings.Split(strings.Join(v, ","), ","))
+		}
+	}
+	rd.addCuries(r)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", mt)
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(status)
+	_, err = w.Write(data)
+	return err
+}
+
+// filterEmbedded keeps the embedded resources of the rels,
+// the others are replaced by links to their self href.
+func (rd *Renderer) filterEmbedded(r *hal.Resource, rels []string) {
+	keep := make(map[string]bool)
+	for _, rel := range rels {
+		keep[strings.ToLower(strings.TrimSpace(rel))] = true
+	}
+	all, _ := r.AllEmbeddedResources()
+	for rel, ers := range all {
+		if keep[strings.ToLower(rel)] {
+			continue
+		}
+		r.RemoveEmbeddedResources(rel)
+		for _, er := range ers {
+			if self, err := er.Link(hal.SELF.Name()); err == nil {
+				r.AddLink(rel, *self)
+			}
+		}
+	}
+}
+
+// addCuries adds the registered CURIEs whose prefix is used by a rel
+// of the resource or of its embedded resources.
+func (rd *Renderer) addCuries(r *hal.Resource) {
+	if len(rd.curies) == 0 {
+		return
+	}
+	used := make(map[string]bool)
+	usedPrefixes(r, used)
+	existing := make(map[string]bool)
+	if cs, err := r.Links(hal.CURIES.Name()); err == nil {
+		for _, c := range cs {
+			existing[c.Name()] = true
+		}
+	}
+	var add []hal.Link
+	for _, c := range rd.curies {
+		if used[c.Name()] && !existing[c.Name()] {
+			add = append(add, c)
+		}
+	}
+	if len(add) > 0 {
+		r.AddLinks(hal.CURIES.Name(), add...)
+	}
+}
+
+// usedPrefixes collects the CURIE prefixes of the rels of r.
+func usedPrefixes(r *hal.Resource, used map[string]bool) {
+	for rel := range r.AllLinks() {
+		if i := strings.Index(rel, ":"); i > 0 {
+			used[rel[:i]] = true
+		}
+	}
+	all, _ := r.AllEmbeddedResources()
+	for rel, ers := range all {
+		if i := strings.Index(rel, ":"); i > 0 {
+			used[rel[:i]] = true
+		}
+		for _, er := range ers {
+			usedPrefixes(er, used)
+		}
+	}
+}
+
+// absoluteSelf resolves the self links of r and of its
+// embedded resources against the base URL.
+func absoluteSelf(r *hal.Resource, base *url.URL) {
+	if ls, err := r.Links(hal.SELF.Name()); err == nil {
+		for i := range ls {
+			if ref, err := url.Parse(ls[i].Href()); err == nil && !ref.IsAbs() && !ls[i].Templated() {
+				ls[i].SetHref(base.ResolveReference(ref).String())
+			}
+		}
+	}
+	all, _ := r.AllEmbeddedResources()
+	for _, ers := range all {
+		for _, er := range ers {
+			absoluteSelf(er, base)
+		}
+	}
+}
+
+// requestURL returns the absolute URL of the request, as seen by the
+// client: the X-Forwarded-Proto header of a proxy gives the scheme.
+func requestURL(req *http.Request) *url.URL {
+	u := *req.URL
+	u.Host = req.Host
+	u.Scheme = "http"
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+	if p := req.Header.Get("X-Forwarded-Proto"); p == "http" || p == "https" {
+		u.Scheme = p
+	}
+	return &u
+}
+
+// negotiate returns the media type to render for the Accept header,
+// or an empty string if none is acceptable. The quality of a media type
+// is the one of the most specific range matching it, as described in
+// RFC 7231 section 5.3.2. At equal quality a media type matched
+// explicitly wins over a wildcard, and application/hal+json over
+// application/json.
+func negotiate(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return MediaTypeHAL
+	}
+	best, bestQ, bestSpec := "", 0.0, -1
+	for _, offer := range []string{MediaTypeHAL, MediaTypeJSON} {
+		q, spec := quality(accept, offer)
+		if q > 0 && (q > bestQ || q == bestQ && spec > bestSpec) {
+			best, bestQ, bestSpec = offer, q, spec
+		}
+	}
+	return best
+}
+
+// quality returns the quality of the media type in the Accept header and
+// the specificity of the range giving it: 2 for the media type itself,
+// 1 for its type/* and 0 for */*, -1 when no range matches.
+func quality(accept, mediaType string) (float64, int) {
+	q, spec := 0.0, -1
+	typ := mediaType[:strings.Index(mediaType, "/")]
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		s := -1
+		switch mt {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= spec {
+			continue
+		}
+		rq := 1.0
+		if v, ok := params["q"]; ok {
+			if rq, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		q, spec = rq, s
+	}
+	return q, spec
+}
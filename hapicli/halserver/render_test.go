@@ -0,0 +1,110 @@
+package halserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestNegotiate(t *testing.T) {
+	data := []struct {
+		Title  string
+		Accept string
+		Out    string
+	}{
+		{"A", "", MediaTypeHAL},
+		{"B", "application/hal+json", MediaTypeHAL},
+		{"C", "application/json", MediaTypeJSON},
+		{"D", "application/json, application/hal+json", MediaTypeHAL},
+		{"E", "application/hal+json;q=0.5, application/json", MediaTypeJSON},
+		{"F", "application/json, */*", MediaTypeJSON},
+		{"G", "*/*", MediaTypeHAL},
+		{"H", "text/html", ""},
+		{"I", "application/hal+json;q=0", ""},
+		{"J", "application/hal+json;q=0, */*", MediaTypeJSON},
+		{"K", "application/*;q=0.2, application/json;q=0, */*", MediaTypeHAL},
+		{"L", "application/hal+json;q=0, application/json;q=0, */*", ""},
+		{"M", "*/*;q=0.5, application/*;q=0.8", MediaTypeHAL},
+	}
+	for _, v := range data {
+		if out := negotiate(v.Accept); out != v.Out {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	rd := NewRenderer()
+	if err := rd.AddCurie("ex", "https://api.example.com/rels/{rel}"); err != nil {
+		t.Fatal(err)
+	}
+	rd.AddCurie("other", "https://api.example.com/other/{rel}")
+	if err := rd.AddCurie("bad", "https://api.example.com/rels"); err == nil {
+		t.Error("expected an error for a CURIE without {rel}")
+	}
+	rd.SetEmbedParam("embed")
+
+	customer := hal.NewBuilder().Self("/customers/1").Prop("name", "bob").MustBuild()
+	mandate := hal.NewBuilder().Self("/mandates/1").Prop("rum", "SLMP000001").MustBuild()
+	order := hal.NewBuilder().
+		Prop("amount", 100).
+		Embed("ex:customer", customer).
+		Embed("ex:mandate", mandate).
+		MustBuild()
+
+	data := []struct {
+		Title     string
+		URL       string
+		Accept    string
+		OutType   string
+		OutStatus int
+		Out       string
+	}{
+		{
+			"A", "/orders/1", "application/hal+json", MediaTypeHAL, 200,
+			`{"_links":{"curies":[{"href":"https://api.example.com/rels/{rel}","templated":true,"name":"ex"}],"self":{"href":"http://api.example.com/orders/1"}},` +
+				`"_embedded":{"ex:customer":{"_links":{"self":{"href":"http://api.example.com/customers/1"}},"name":"bob"},` +
+				`"ex:mandate":{"_links":{"self":{"href":"http://api.example.com/mandates/1"}},"rum":"SLMP000001"}},"amount":100}`,
+		},
+		{
+			"B", "/orders/1?embed=ex:customer", "application/json", MediaTypeJSON, 200,
+			`{"_links":{"curies":[{"href":"https://api.example.com/rels/{rel}","templated":true,"name":"ex"}],` +
+				`"ex:mandate":{"href":"http://api.example.com/mandates/1"},"self":{"href":"http://api.example.com/orders/1?embed=ex:customer"}},` +
+				`"_embedded":{"ex:customer":{"_links":{"self":{"href":"http://api.example.com/customers/1"}},"name":"bob"}},"amount":100}`,
+		},
+		{"C", "/orders/1", "text/html", "text/plain; charset=utf-8", 406, ""},
+	}
+	for _, v := range data {
+		req := httptest.NewRequest("GET", "http://api.example.com"+v.URL, nil)
+		req.Header.Set("Accept", v.Accept)
+		w := httptest.NewRecorder()
+		rd.Render(w, req, http.StatusOK, order)
+
+		if w.Code != v.OutStatus || w.Header().Get("Content-Type") != v.OutType {
+			t.Error("for test", v.Title, "expected", v.OutStatus, v.OutType, "got", w.Code, w.Header().Get("Content-Type"))
+		}
+		if v.Out == "" {
+			continue
+		}
+		var a, b interface{}
+		json.Unmarshal([]byte(v.Out), &a)
+		json.Unmarshal(w.Body.Bytes(), &b)
+		if !equal(a, b) {
+			t.Error("for test", v.Title, "expected", v.Out, "got", w.Body.String())
+		}
+	}
+
+	// the rendered resource is not modified
+	if _, err := order.Link("self"); err != hal.ErrRelNotFound {
+		t.Error("expected the resource not to be modified got", err)
+	}
+}
+
+func equal(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
@@ -0,0 +1,195 @@
+// Package haltest provides an in-process HAL server for the tests of the
+// code using hapicli. The resources it serves are declared with the hal
+// types:
+//
+//	srv := haltest.NewServer()
+//	defer srv.Close()
+//	srv.Handle("/orders/1", hal.NewBuilder().Self("/orders/1").Prop("amount", 100).MustBuild())
+//
+//	client := hapicli.NewClient(nil)
+//	client.SetAPIURL(srv.URL)
+package haltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// MediaType is the content type of the served resources.
+const MediaType = "application/hal+json"
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	// The request URI: the path and the query.
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// response is what the server answers on a route.
+type response struct {
+	status   int
+	resource *hal.Resource
+	// The pages of a collection, served by page number when not nil.
+	pages []*hal.Resource
+}
+
+// Server is the fake HAL server, an httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string]response
+	latency  time.Duration
+	requests []Request
+}
+
+// NewServer starts a Server, it must be closed at the end of the test.
+func NewServer() *Server {
+	s := &Server{routes: make(map[string]response)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Handle serves the resource on the GET requests of the path.
+// The path may have a query, matched before the path alone.
+func (s *Server) Handle(path string, r *hal.Resource) {
+	s.HandleMethod("GET", path, http.StatusOK, r)
+}
+
+// HandleMethod answers the requests of the method on the path
+// with the status and the resource, which may be nil.
+func (s *Server) HandleMethod(method, path string, status int, r *hal.Resource) {
+	s.mu.Lock()
+	s.routes[method+" "+path] = response{status: status, resource: r}
+	s.mu.Unlock()
+}
+
+// HandleError answers the requests of the method on the path with
+// an error status and a resource holding the message.
+func (s *Server) HandleError(method, path string, status int, message string) {
+	r := new(hal.Resource)
+	r.SetProperty("message", message)
+	s.HandleMethod(method, path, status, r)
+}
+
+// HandlePages serves the items as a collection: the pages of perPage
+// items embedded with rel are served at path?page=N (from 1, the first page
+// being the default) and linked by the self, first, last, next and prev rels.
+func (s *Server) HandlePages(path, rel string, perPage int, items ...*hal.Resource) {
+	if perPage <= 0 {
+		perPage = len(items)
+	}
+	n := (len(items) + perPage - 1) / perPage
+	if n == 0 {
+		n = 1
+	}
+	href := func(i int) hal.Link {
+		l, _ := hal.NewLink(path+"?page="+strconv.Itoa(i), hal.LinkOptionalParam{})
+		return *l
+	}
+	pages := make([]*hal.Resource, n)
+	for i := range pages {
+		p := new(hal.Resource)
+		p.AddLink(hal.SELF.Name(), href(i+1))
+		p.AddLink(hal.FIRST.Name(), href(1))
+		p.AddLink(hal.LAST.Name(), href(n))
+		if i > 0 {
+			p.AddLink(hal.PREV.Name(), href(i))
+		}
+		if i < n-1 {
+			p.AddLink(hal.NEXT.Name(), href(i+2))
+		}
+		end := (i + 1) * perPage
+		if end > len(items) {
+			end = len(items)
+		}
+		p.AddEmbeddedResources(rel, items[i*perPage:end]...)
+		p.SetProperty("page", i+1)
+		p.SetProperty("totalPages", n)
+		pages[i] = p
+	}
+	s.mu.Lock()
+	s.routes["GET "+path] = response{status: http.StatusOK, pages: pages}
+	s.mu.Unlock()
+}
+
+// SetLatency is delaying each response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	s.latency = d
+	s.mu.Unlock()
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{r.Method, r.URL.RequestURI(), r.Header, body})
+	latency := s.latency
+	resp, ok := s.routes[r.Method+" "+r.URL.RequestURI()]
+	if !ok {
+		resp, ok = s.routes[r.Method+" "+r.URL.Path]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if !ok {
+		notFound := new(hal.Resource)
+		notFound.SetProperty("message", fmt.Sprintf("no resource for %s %s", r.Method, r.URL.RequestURI()))
+		write(w, http.StatusNotFound, notFound)
+		return
+	}
+	if resp.pages != nil {
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			page = 1
+		}
+		if page < 1 || page > len(resp.pages) {
+			invalid := new(hal.Resource)
+			invalid.SetProperty("message", fmt.Sprintf("no page %d", page))
+			write(w, http.StatusNotFound, invalid)
+			return
+		}
+		resp.resource = resp.pages[page-1]
+	}
+	write(w, resp.status, resp.resource)
+}
+
+// write writes the resource with the HAL content type.
+func write(w http.ResponseWriter, status int, r *hal.Resource) {
+	if r == nil {
+		w.WriteHeader(status)
+		return
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,105 @@
+package haltest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ritoon/hapiclient-go/hapicli"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func newServer() *Server {
+	srv := NewServer()
+	srv.Handle("/", hal.NewBuilder().
+		Self("/").
+		Href("ex:orders", "/orders{?page}", hal.LinkOptionalParam{Templated: true}).
+		Href("ex:order", "/orders/{id}", hal.LinkOptionalParam{Templated: true}).
+		Href("ex:broken", "/broken", hal.LinkOptionalParam{}).
+		MustBuild())
+
+	var items []*hal.Resource
+	for i := 1; i <= 5; i++ {
+		item := hal.NewBuilder().Self(fmt.Sprintf("/orders/%d", i)).Prop("id", i).MustBuild()
+		items = append(items, item)
+		srv.Handle(fmt.Sprintf("/orders/%d", i), item)
+	}
+	srv.HandlePages("/orders", "ex:orders", 2, items...)
+	srv.HandleError("GET", "/broken", 503, "try later")
+	return srv
+}
+
+func TestServer(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	c := hapicli.NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	orders, _ := hal.NewCustomRel("ex:orders")
+	order, _ := hal.NewCustomRel("ex:order")
+	broken, _ := hal.NewCustomRel("ex:broken")
+	id3, _ := hapicli.NewRequest("GET", []string{"id=3"}, "", "")
+
+	res, err := c.SendFollow(context.Background(), nil, hapicli.NewFollow(order, id3))
+	if err != nil || res.State()["id"] != float64(3) {
+		t.Error("expected order 3 got", res, err)
+	}
+
+	page, err := c.SendFollow(context.Background(), nil, hapicli.NewFollow(orders, nil))
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	var ids []interface{}
+	it := hapicli.NewPageIterator(c, page, orders)
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Item().State()["id"])
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" || it.Err() != nil {
+		t.Error("expected the 5 orders got", ids, it.Err())
+	}
+
+	_, err = c.SendFollow(context.Background(), nil, hapicli.NewFollow(broken, nil))
+	if e, ok := err.(*hapicli.ErrResponse); !ok || e.StatusCode != 503 || string(e.Body) != "{\"message\":\"try later\"}\n" {
+		t.Error("expected the 503 error got", err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 8 {
+		t.Fatal("expected 8 requests got", reqs)
+	}
+	if reqs[1].URL != "/orders/3" || reqs[5].URL != "/orders?page=3" || reqs[1].Method != "GET" {
+		t.Error("expected the requests in order got", reqs)
+	}
+}
+
+func TestServerMethodsAndLatency(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.HandleMethod("POST", "/orders", 201, hal.NewBuilder().Prop("id", 6).MustBuild())
+	srv.SetLatency(20 * time.Millisecond)
+
+	c := hapicli.NewClient(nil)
+	post, _ := hapicli.NewRequest("POST", nil, `{"label":"new"}`, "")
+	start := time.Now()
+	res, err := c.Send(context.Background(), srv.URL+"/orders", post)
+	if err != nil || res.State()["id"] != float64(6) {
+		t.Error("expected the created order got", res, err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("expected the latency to be injected")
+	}
+	if reqs := srv.Requests(); string(reqs[0].Body) != `{"label":"new"}` {
+		t.Error("expected the body to be recorded got", reqs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	get, _ := hapicli.NewRequest("GET", nil, "", "")
+	if _, err := c.Send(ctx, srv.URL+"/unknown", get); err == nil {
+		t.Error("expected the request to time out")
+	}
+	srv.SetLatency(0)
+	if _, err := c.Send(context.Background(), srv.URL+"/unknown", get); err == nil {
+		t.Error("expected a 404 error")
+	}
+}
@@ -2,6 +2,7 @@ package hapicli
 
 import (
 	"errors"
+	"net/http"
 	"strings"
 )
 
@@ -52,7 +53,6 @@ func NewRequest(method string, urlVariables []string, messageBody string, header
 		headers:      headers,
 	}
 
-	//print(r)
 	// return the Request and no error
 	return r, nil
 }
@@ -78,3 +78,17 @@ func (r *Request) MessageBody() string {
 func (r *Request) Headers() string {
 	return r.headers
 }
+
+// ParseHeaders reads the optional headers of a request,
+// one "Name: value" pair per line.
+func ParseHeaders(s string) http.Header {
+	h := make(http.Header)
+	for _, line := range strings.Split(s, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		h.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	return h
+}
@@ -0,0 +1,83 @@
+package hapicli
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// Exchange is a request of the client as seen by the interceptors:
+// the high-level request and the link it follows, with the
+// http.Request built from them.
+type Exchange struct {
+	Request AbstractRequester
+	// The relation type followed, empty when the request
+	// was sent to a URL.
+	Rel string
+	// The link followed, nil when the request was sent to a URL.
+	Link *hal.Link
+	// The number of the attempt, from 1.
+	Attempt int
+	// The request sent to the server, the interceptors may
+	// change it or replace it before calling the next one.
+	HTTPRequest *http.Request
+}
+
+var errorNoResponse = errors.New("Hapicli: interceptor returned no response")
+
+// RoundTrip sends the exchange to the next interceptor,
+// or to the server after the last one.
+type RoundTrip func(e *Exchange) (*http.Response, error)
+
+// Interceptor sees each request of the client. It calls next to
+// go on with the request, or short-circuits it by returning its own
+// response or error without calling next.
+// An error returned by an interceptor is handled as a network error:
+// the GET, PUT and DELETE requests are sent again, through the whole
+// chain, as many times as set with SetMaxRetries.
+type Interceptor interface {
+	Intercept(e *Exchange, next RoundTrip) (*http.Response, error)
+}
+
+// InterceptorFunc is a function used as an Interceptor.
+type InterceptorFunc func(e *Exchange, next RoundTrip) (*http.Response, error)
+
+// Intercept calls f(e, next).
+func (f InterceptorFunc) Intercept(e *Exchange, next RoundTrip) (*http.Response, error) {
+	return f(e, next)
+}
+
+// SetInterceptors is setting the chain of interceptors of the client.
+// They are called in the given order: the first one sees the request
+// first and the response last.
+func (c *Client) SetInterceptors(is ...Interceptor) {
+	c.interceptors = is
+}
+
+// intercept sends the exchange through the chain of interceptors.
+func (c *Client) intercept(e *Exchange) (*http.Response, error) {
+	var next func(i int) RoundTrip
+	next = func(i int) RoundTrip {
+		if i == len(c.interceptors) {
+			return func(e *Exchange) (*http.Response, error) {
+				return c.httpClient.Do(e.HTTPRequest)
+			}
+		}
+		return func(e *Exchange) (*http.Response, error) {
+			return c.interceptors[i].Intercept(e, next(i+1))
+		}
+	}
+	resp, err := next(0)(e)
+	switch {
+	case resp == nil && err == nil:
+		return nil, errorNoResponse
+	case resp != nil && err != nil:
+		// the response given with an error is not read
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, err
+}
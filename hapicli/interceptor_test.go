@@ -0,0 +1,135 @@
+package hapicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// tracingInterceptor returns an interceptor writing its name and the rel
+// of the exchange to calls, before and after the next one.
+func tracingInterceptor(name string, calls *[]string) Interceptor {
+	return InterceptorFunc(func(e *Exchange, next RoundTrip) (*http.Response, error) {
+		*calls = append(*calls, name+">"+e.Rel)
+		resp, err := next(e)
+		*calls = append(*calls, "<"+name)
+		return resp, err
+	})
+}
+
+func TestInterceptors(t *testing.T) {
+	var tenants []string
+	srv := newCollectionServer(2, 1)
+	defer srv.Close()
+	tenant := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer tenant.Close()
+
+	var calls []string
+	c := NewClient(nil)
+	c.SetAPIURL(tenant.URL)
+	c.SetInterceptors(
+		tracingInterceptor("A", &calls),
+		InterceptorFunc(func(e *Exchange, next RoundTrip) (*http.Response, error) {
+			e.HTTPRequest.Header.Set("X-Tenant", "acme")
+			return next(e)
+		}),
+		tracingInterceptor("B", &calls),
+	)
+	orders, _ := hal.NewCustomRel("ex:orders")
+	if _, err := c.SendFollow(context.Background(), nil, NewFollow(orders, nil)); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+
+	want := "[A> B> <B <A A>ex:orders B>ex:orders <B <A]"
+	if fmt.Sprint(calls) != want {
+		t.Error("expected", want, "got", calls)
+	}
+	if fmt.Sprint(tenants) != "[acme acme]" {
+		t.Error("expected the tenant header on each request got", tenants)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	srv := newCollectionServer(2, 1)
+	defer srv.Close()
+
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	c.SetInterceptors(InterceptorFunc(func(e *Exchange, next RoundTrip) (*http.Response, error) {
+		if e.Rel != "ex:orders" || e.Request.Method() != "GET" {
+			return next(e)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/hal+json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"page":42}`)),
+			Request:    e.HTTPRequest,
+		}, nil
+	}))
+	orders, _ := hal.NewCustomRel("ex:orders")
+	res, err := c.SendFollow(context.Background(), nil, NewFollow(orders, nil))
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if res.State()["page"] != float64(42) {
+		t.Error("expected the response of the interceptor got", res.State())
+	}
+}
+
+func TestInterceptorNoResponse(t *testing.T) {
+	srv := newCollectionServer(2, 1)
+	defer srv.Close()
+
+	calls := 0
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	c.SetMaxRetries(1)
+	c.SetInterceptors(InterceptorFunc(func(e *Exchange, next RoundTrip) (*http.Response, error) {
+		calls++
+		return nil, nil
+	}))
+	if _, err := c.EntryPoint(context.Background()); err != errorNoResponse {
+		t.Error("expected", errorNoResponse, "got", err)
+	}
+	if calls != 2 {
+		t.Error("expected the GET request to be retried once got", calls, "calls")
+	}
+}
+
+// closeRecorder records if the body is closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestInterceptorResponseAndError(t *testing.T) {
+	errIntercepted := errors.New("intercepted")
+	body := &closeRecorder{Reader: strings.NewReader("{}")}
+	c := NewClient(nil)
+	c.SetAPIURL("http://example.com")
+	c.SetInterceptors(InterceptorFunc(func(e *Exchange, next RoundTrip) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: body, Request: e.HTTPRequest}, errIntercepted
+	}))
+	if _, err := c.EntryPoint(context.Background()); err != errIntercepted {
+		t.Error("expected", errIntercepted, "got", err)
+	}
+	if !body.closed {
+		t.Error("expected the body of the response to be closed")
+	}
+}
@@ -0,0 +1,199 @@
+package hapicli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The fields always redacted from the logged bodies: the OAuth tokens.
+var oauthFields = []string{"access_token", "refresh_token", "id_token", "client_secret"}
+
+// The headers whose value is never logged.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+const redacted = "REDACTED"
+
+// Logger is the logger of the client.
+// The args are alternating keys and values, so a *slog.Logger can be used.
+//
+// Each request is logged at the debug level with its method, URL, followed
+// rel, attempt, headers and body, and so is its response with the status
+// and the duration. The failed requests and the deprecations are warnings.
+//
+// The debug events, and the redaction of their bodies, are skipped when
+// the Logger has a DebugEnabled() bool method returning false, or an
+// Enabled(context.Context, level) bool method returning false for the
+// debug level of log/slog, as a *slog.Logger does.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+// stdLogger is the default Logger, writing the warnings
+// to the standard logger of the log package.
+type stdLogger struct{}
+
+func (stdLogger) Debug(msg string, args ...interface{}) {}
+
+func (stdLogger) DebugEnabled() bool { return false }
+
+func (stdLogger) Warn(msg string, args ...interface{}) {
+	log.Print("WARN " + msg + formatArgs(args))
+}
+
+// SetRedactedFields is setting the fields of the JSON bodies whose value
+// is not logged, e.g. "iban". The OAuth tokens are always redacted.
+func (c *Client) SetRedactedFields(fields ...string) {
+	c.redactedFields = make(map[string]bool)
+	for _, f := range append(fields, oauthFields...) {
+		c.redactedFields[strings.ToLower(f)] = true
+	}
+}
+
+// slogDebug is the value of slog.LevelDebug.
+const slogDebug = -4
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// debugEnabled tells if the logger writes the debug events.
+func (c *Client) debugEnabled(ctx context.Context) bool {
+	if l, ok := c.logger.(interface {
+		DebugEnabled() bool
+	}); ok {
+		return l.DebugEnabled()
+	}
+	// The level type of the Enabled method of a *slog.Logger is
+	// slog.Level, which is read by reflection.
+	m := reflect.ValueOf(c.logger).MethodByName("Enabled")
+	if !m.IsValid() {
+		return true
+	}
+	t := m.Type()
+	if t.NumIn() != 2 || t.In(0) != contextType || t.In(1).Kind() != reflect.Int ||
+		t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool {
+		return true
+	}
+	out := m.Call([]reflect.Value{reflect.ValueOf(&ctx).Elem(), reflect.ValueOf(slogDebug).Convert(t.In(1))})
+	return out[0].Bool()
+}
+
+// logRequest logs the request sent for the call.
+func (c *Client) logRequest(cl *call, req *http.Request) {
+	if !c.debugEnabled(req.Context()) {
+		return
+	}
+	c.logger.Debug("hapicli request",
+		"method", req.Method,
+		"url", redactURL(req.URL),
+		"rel", cl.rel,
+		"attempt", cl.attempt,
+		"headers", redactHeader(req.Header),
+		"body", c.redactBody([]byte(cl.request.MessageBody())))
+}
+
+// logResponse logs the response received for the call, or its error.
+func (c *Client) logResponse(cl *call, req *http.Request, resp *http.Response, body []byte, d time.Duration, err error) {
+	if err != nil {
+		c.logger.Warn("hapicli request failed",
+			"method", req.Method,
+			"url", redactURL(req.URL),
+			"rel", cl.rel,
+			"attempt", cl.attempt,
+			"duration", d,
+			"error", err)
+		return
+	}
+	if !c.debugEnabled(req.Context()) {
+		return
+	}
+	c.logger.Debug("hapicli response",
+		"method", req.Method,
+		"url", redactURL(req.URL),
+		"rel", cl.rel,
+		"attempt", cl.attempt,
+		"status", resp.StatusCode,
+		"duration", d,
+		"body", c.redactBody(body))
+}
+
+// redactURL returns the URL without the access token of its query.
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	if q.Get("access_token") == "" {
+		return u.String()
+	}
+	q.Set("access_token", redacted)
+	r := *u
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// redactHeader returns a copy of h without the credentials.
+func redactHeader(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		out[k] = v
+	}
+	for _, k := range redactedHeaders {
+		if out.Get(k) != "" {
+			out.Set(k, redacted)
+		}
+	}
+	return out
+}
+
+// redactBody returns the JSON body with the value of the
+// redacted fields replaced, at any depth.
+// The bodies which are not JSON are only described by their size.
+func (c *Client) redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	out, _ := json.Marshal(c.redactValue(v))
+	return string(out)
+}
+
+func (c *Client) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, w := range t {
+			if c.redactedFields[strings.ToLower(k)] {
+				t[k] = redacted
+				continue
+			}
+			t[k] = c.redactValue(w)
+		}
+	case []interface{}:
+		for i, w := range t {
+			t[i] = c.redactValue(w)
+		}
+	}
+	return v
+}
+
+// formatArgs formats the alternating keys and values as " key=value".
+func formatArgs(args []interface{}) string {
+	var s []string
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			s = append(s, fmt.Sprint(args[i]))
+			break
+		}
+		s = append(s, fmt.Sprintf("%v=%v", args[i], args[i+1]))
+	}
+	if len(s) == 0 {
+		return ""
+	}
+	return " " + strings.Join(s, " ")
+}
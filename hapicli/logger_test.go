@@ -0,0 +1,119 @@
+package hapicli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// arg returns the value of the key in the args of a logged event.
+func arg(event []interface{}, key string) interface{} {
+	for i := 1; i+1 < len(event); i += 2 {
+		if event[i] == key {
+			return event[i+1]
+		}
+	}
+	return nil
+}
+
+func TestLogRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Write([]byte(`{"iban":"FR7630006000011234567890189","access_token":"secret","id":1}`))
+	}))
+	defer srv.Close()
+
+	l := &recordLogger{}
+	c := NewClient(nil)
+	c.SetLogger(l)
+	c.SetRedactedFields("IBAN")
+	r, _ := NewRequest("POST", nil, `{"debtor":{"iban":"FR7630006000011234567890189"},"label":"x"}`, "Authorization: Bearer secret\nX-Tenant: acme")
+	if _, err := c.Send(context.Background(), srv.URL+"/orders?access_token=secret", r); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if len(l.debugs) != 2 {
+		t.Fatal("expected a request and a response event got", l.debugs)
+	}
+
+	req, resp := l.debugs[0], l.debugs[1]
+	data := []struct {
+		Title string
+		Event []interface{}
+		Key   string
+		Out   interface{}
+	}{
+		{"A", req, "method", "POST"},
+		{"B", req, "url", srv.URL + "/orders?access_token=" + redacted},
+		{"C", req, "attempt", 1},
+		{"D", req, "body", `{"debtor":{"iban":"REDACTED"},"label":"x"}`},
+		{"E", resp, "status", 200},
+		{"F", resp, "body", `{"access_token":"REDACTED","iban":"REDACTED","id":1}`},
+	}
+	for _, v := range data {
+		if out := arg(v.Event, v.Key); out != v.Out {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+	h := arg(req, "headers").(http.Header)
+	if h.Get("Authorization") != redacted || h.Get("X-Tenant") != "acme" {
+		t.Error("expected the Authorization header to be redacted got", h)
+	}
+	if _, ok := arg(resp, "duration").(time.Duration); !ok {
+		t.Error("expected a duration got", arg(resp, "duration"))
+	}
+}
+
+// quietLogger is a recordLogger whose debug level is disabled.
+type quietLogger struct {
+	recordLogger
+}
+
+func (*quietLogger) DebugEnabled() bool { return false }
+
+// level is a level of levelLogger, as slog.Level.
+type level int
+
+// levelLogger is a recordLogger enabled from a level, as a *slog.Logger.
+type levelLogger struct {
+	recordLogger
+	min level
+}
+
+func (l *levelLogger) Enabled(ctx context.Context, lvl level) bool { return lvl >= l.min }
+
+func TestDebugDisabled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	quiet, info, debug := &quietLogger{}, &levelLogger{min: 0}, &levelLogger{min: -4}
+	data := []struct {
+		Title     string
+		Logger    Logger
+		Debugs    func() [][]interface{}
+		OutDebugs int
+	}{
+		{"A", quiet, func() [][]interface{} { return quiet.debugs }, 0},
+		{"B", info, func() [][]interface{} { return info.debugs }, 0},
+		{"C", debug, func() [][]interface{} { return debug.debugs }, 2},
+	}
+	for _, v := range data {
+		c := NewClient(nil)
+		c.SetLogger(v.Logger)
+		r, _ := NewRequest("POST", nil, `{"label":"x"}`, "")
+		if _, err := c.Send(context.Background(), srv.URL, r); err != nil {
+			t.Fatal("for test", v.Title, "expected no error got", err)
+		}
+		if n := len(v.Debugs()); n != v.OutDebugs {
+			t.Error("for test", v.Title, "expected", v.OutDebugs, "debug events got", n)
+		}
+	}
+	c := NewClient(nil)
+	if c.debugEnabled(context.Background()) {
+		t.Error("expected the debug level of the default logger to be disabled")
+	}
+}
@@ -0,0 +1,53 @@
+package hapicli
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics collects the measures of the client. The requests are keyed
+// by their method and the relation type they follow, not by their URL.
+type Metrics interface {
+	// RequestStarted is called when a request is sent.
+	RequestStarted(method, rel string)
+	// RequestDone is called when its response is received, statusClass
+	// being "2xx" to "5xx" or "error" when no response was received.
+	RequestDone(method, rel, statusClass string, d time.Duration)
+	// Retried is called when a request is sent again.
+	Retried(method, rel string)
+	// Cached is called for each response, hit being true when the
+	// response comes from a cache (see isCached).
+	Cached(method, rel string, hit bool)
+}
+
+// SetMetrics is setting the metrics collector of the client
+func (c *Client) SetMetrics(m Metrics) {
+	c.metrics = m
+}
+
+// statusClass returns the class of the status code of the response.
+func statusClass(resp *http.Response, err error) string {
+	if err != nil || resp == nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode/100) + "xx"
+}
+
+// isCached tells if the response comes from a cache: it is a
+// 304 Not Modified, or it has an Age header set by a shared cache
+// (see RFC7234 section 5.1) or the X-From-Cache header set by
+// the caching transports.
+func isCached(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusNotModified ||
+		resp.Header.Get("Age") != "" ||
+		resp.Header.Get("X-From-Cache") != ""
+}
+
+// noopMetrics is the collector used when the client has none.
+type noopMetrics struct{}
+
+func (noopMetrics) RequestStarted(method, rel string)                            {}
+func (noopMetrics) RequestDone(method, rel, statusClass string, d time.Duration) {}
+func (noopMetrics) Retried(method, rel string)                                   {}
+func (noopMetrics) Cached(method, rel string, hit bool)                          {}
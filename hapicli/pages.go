@@ -0,0 +1,217 @@
+package hapicli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+var errorPageCycle = errors.New("Hapicli: the page has already been read, the collection links are cycling")
+
+// PageIterator yields the embedded resources of a HAL collection
+// one by one, across the pages linked by the registered
+// relation types next and prev.
+//
+//	it := hapicli.NewPageIterator(client, orders, rel)
+//	for it.Next(ctx) {
+//		order := it.Item()
+//		...
+//	}
+//	err := it.Err()
+//
+// The URL of the current page can be saved with PageURL
+// and the iteration resumed later with ResumePageIterator.
+type PageIterator struct {
+	client *Client
+	rel    hal.Rel
+
+	// The relation type followed to get the next page: NEXT or PREV.
+	direction hal.Rel
+
+	page  *hal.Resource
+	items []*hal.Resource
+	item  *hal.Resource
+	err   error
+
+	// Limits of the iteration, no limit when 0.
+	maxItems int
+	maxPages int
+
+	pages int
+	count int
+
+	// The self hrefs of the pages read since the last jump
+	// to the first or last page.
+	visited map[string]bool
+}
+
+// NewPageIterator create a PageIterator
+// - param c		*Client			The client following the links between pages
+// - param page		*hal.Resource	The first page of the iteration
+// - param rel		hal.Rel			The relation type of the embedded items
+func NewPageIterator(c *Client, page *hal.Resource, rel hal.Rel) *PageIterator {
+	it := &PageIterator{client: c, rel: rel, direction: hal.NEXT}
+	it.setPage(page)
+	it.visit()
+	return it
+}
+
+// ResumePageIterator create a PageIterator starting
+// from a page URL saved with PageURL.
+func ResumePageIterator(ctx context.Context, c *Client, pageURL string, rel hal.Rel) (*PageIterator, error) {
+	page, err := c.Send(ctx, pageURL, &Request{method: "GET"})
+	if err != nil {
+		return nil, err
+	}
+	return NewPageIterator(c, page, rel), nil
+}
+
+// SetMaxItems is setting the maximum number of items to yield
+func (it *PageIterator) SetMaxItems(n int) {
+	it.maxItems = n
+}
+
+// SetMaxPages is setting the maximum number of pages to read
+func (it *PageIterator) SetMaxPages(n int) {
+	it.maxPages = n
+}
+
+// SetBackward is making the iteration follow the prev links
+// instead of the next links
+func (it *PageIterator) SetBackward(b bool) {
+	it.direction = hal.NEXT
+	if b {
+		it.direction = hal.PREV
+	}
+}
+
+// Next moves to the next item, fetching the following page when the
+// items of the current one are exhausted. It returns false at the end
+// of the collection, when a limit is reached or when an error occurs,
+// e.g. a page read twice because the links are cycling.
+func (it *PageIterator) Next(ctx context.Context) bool {
+	if it.err != nil || it.page == nil {
+		return false
+	}
+	if it.maxItems > 0 && it.count >= it.maxItems {
+		return false
+	}
+	for len(it.items) == 0 {
+		if it.maxPages > 0 && it.pages >= it.maxPages {
+			return false
+		}
+		if _, err := it.page.Link(it.direction.Name()); err != nil {
+			return false
+		}
+		if !it.jump(ctx, it.direction) {
+			return false
+		}
+		if !it.visit() {
+			it.err = errorPageCycle
+			return false
+		}
+	}
+	if it.err = ctx.Err(); it.err != nil {
+		return false
+	}
+	it.item, it.items = it.items[0], it.items[1:]
+	it.count++
+	return true
+}
+
+// First moves the iteration to the first page of the collection.
+func (it *PageIterator) First(ctx context.Context) error {
+	if it.jump(ctx, hal.FIRST) {
+		it.visited = nil
+		it.visit()
+	}
+	return it.err
+}
+
+// Last moves the iteration to the last page of the collection.
+func (it *PageIterator) Last(ctx context.Context) error {
+	if it.jump(ctx, hal.LAST) {
+		it.visited = nil
+		it.visit()
+	}
+	return it.err
+}
+
+// Item returns the current item.
+func (it *PageIterator) Item() *hal.Resource {
+	return it.item
+}
+
+// Page returns the current page.
+func (it *PageIterator) Page() *hal.Resource {
+	return it.page
+}
+
+// PageURL returns the URL of the current page, from its self link,
+// or an empty string if the page has none.
+func (it *PageIterator) PageURL() string {
+	if it.page == nil {
+		return ""
+	}
+	l, err := it.page.Link(hal.SELF.Name())
+	if err != nil {
+		return ""
+	}
+	return l.Href()
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// jump follows the rel from the current page.
+func (it *PageIterator) jump(ctx context.Context, rel hal.Rel) bool {
+	if it.err = ctx.Err(); it.err != nil {
+		return false
+	}
+	if it.page == nil {
+		it.err = errorNoResource
+		return false
+	}
+	page, err := it.client.SendFollow(ctx, it.page, NewFollow(rel, nil))
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.setPage(page)
+	if page == nil {
+		it.err = errorNoResource
+		return false
+	}
+	return true
+}
+
+// visit records the self href of the current page, it returns
+// false if the page has already been read.
+func (it *PageIterator) visit() bool {
+	href := it.PageURL()
+	if href == "" {
+		return true
+	}
+	if it.visited[href] {
+		return false
+	}
+	if it.visited == nil {
+		it.visited = make(map[string]bool)
+	}
+	it.visited[href] = true
+	return true
+}
+
+// setPage makes page the current page.
+func (it *PageIterator) setPage(page *hal.Resource) {
+	it.page = page
+	it.items = nil
+	if page == nil {
+		return
+	}
+	it.pages++
+	it.items, _ = page.EmbeddedResources(it.rel.Name())
+}
@@ -0,0 +1,184 @@
+package hapicli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// newCollectionServer serves an entry point linking to a collection of
+// orders spread over the given number of pages of perPage items each.
+func newCollectionServer(pages, perPage int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/hal+json")
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `{"_links":{"self":{"href":"/"},"ex:orders":{"href":"/orders{?page}","templated":true}}}`)
+			return
+		}
+		if r.URL.Path != "/orders" {
+			http.NotFound(w, r)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 || page > pages {
+			page = 1
+		}
+		links := []string{
+			fmt.Sprintf(`"self":{"href":"/orders?page=%d"}`, page),
+			`"first":{"href":"/orders?page=1"}`,
+			fmt.Sprintf(`"last":{"href":"/orders?page=%d"}`, pages),
+		}
+		if page < pages {
+			links = append(links, fmt.Sprintf(`"next":{"href":"/orders?page=%d"}`, page+1))
+		}
+		if page > 1 {
+			links = append(links, fmt.Sprintf(`"prev":{"href":"/orders?page=%d"}`, page-1))
+		}
+		var items []string
+		for i := 1; i <= perPage; i++ {
+			items = append(items, fmt.Sprintf(`{"id":%d}`, (page-1)*perPage+i))
+		}
+		fmt.Fprintf(w, `{"_links":{%s},"_embedded":{"ex:orders":[%s]},"page":%d}`,
+			strings.Join(links, ","), strings.Join(items, ","), page)
+	}))
+}
+
+// collect returns the ids of the items yielded by the iterator.
+func collect(it *PageIterator) []int {
+	var ids []int
+	for it.Next(context.Background()) {
+		ids = append(ids, int(it.Item().State()["id"].(float64)))
+	}
+	return ids
+}
+
+func TestPageIterator(t *testing.T) {
+	srv := newCollectionServer(3, 2)
+	defer srv.Close()
+	rel, _ := hal.NewCustomRel("ex:orders")
+
+	data := []struct {
+		Title    string
+		Page     string
+		Backward bool
+		MaxItems int
+		MaxPages int
+		Out      string
+	}{
+		{"A", "1", false, 0, 0, "[1 2 3 4 5 6]"},
+		{"B", "1", false, 3, 0, "[1 2 3]"},
+		{"C", "1", false, 0, 2, "[1 2 3 4]"},
+		{"D", "3", true, 0, 0, "[5 6 3 4 1 2]"},
+		{"E", "2", false, 0, 0, "[3 4 5 6]"},
+	}
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	for _, v := range data {
+		it, err := ResumePageIterator(context.Background(), c, "/orders?page="+v.Page, rel)
+		if err != nil {
+			t.Fatal("for test", v.Title, "expected no error got", err)
+		}
+		it.SetBackward(v.Backward)
+		it.SetMaxItems(v.MaxItems)
+		it.SetMaxPages(v.MaxPages)
+		if out := fmt.Sprint(collect(it)); out != v.Out {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+		if it.Err() != nil {
+			t.Error("for test", v.Title, "expected no error got", it.Err())
+		}
+	}
+}
+
+func TestPageIteratorFirstLast(t *testing.T) {
+	srv := newCollectionServer(3, 2)
+	defer srv.Close()
+	rel, _ := hal.NewCustomRel("ex:orders")
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+
+	it, _ := ResumePageIterator(context.Background(), c, "/orders?page=2", rel)
+	if err := it.Last(context.Background()); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if it.PageURL() != "/orders?page=3" {
+		t.Error("expected the last page got", it.PageURL())
+	}
+	if err := it.First(context.Background()); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if out := fmt.Sprint(collect(it)); out != "[1 2 3 4 5 6]" {
+		t.Error("expected all the items got", out)
+	}
+}
+
+func TestPageIteratorCancel(t *testing.T) {
+	srv := newCollectionServer(3, 2)
+	defer srv.Close()
+	rel, _ := hal.NewCustomRel("ex:orders")
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+
+	it, _ := ResumePageIterator(context.Background(), c, "/orders", rel)
+	ctx, cancel := context.WithCancel(context.Background())
+	it.Next(ctx)
+	cancel()
+	if it.Next(ctx) {
+		t.Error("expected the iteration to stop")
+	}
+	if it.Err() != context.Canceled {
+		t.Error("expected", context.Canceled, "got", it.Err())
+	}
+}
+
+func TestPageIteratorBrokenLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/empty":
+			w.Header().Set("Content-Type", "application/hal+json")
+			fmt.Fprint(w, `{"_links":{"self":{"href":"/empty"},"next":{"href":"/none"}},"_embedded":{"ex:orders":[{"id":1}]}}`)
+		case "/none":
+			w.WriteHeader(http.StatusNoContent)
+		case "/cycle":
+			w.Header().Set("Content-Type", "application/hal+json")
+			fmt.Fprint(w, `{"_links":{"self":{"href":"/cycle"},"next":{"href":"/cycle"}},"_embedded":{"ex:orders":[]}}`)
+		case "/loop1", "/loop2":
+			next := map[string]string{"/loop1": "/loop2", "/loop2": "/loop1"}[r.URL.Path]
+			w.Header().Set("Content-Type", "application/hal+json")
+			fmt.Fprintf(w, `{"_links":{"self":{"href":"%s"},"next":{"href":"%s"}},"_embedded":{"ex:orders":[{"id":2}]}}`, r.URL.Path, next)
+		}
+	}))
+	defer srv.Close()
+	rel, _ := hal.NewCustomRel("ex:orders")
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+
+	data := []struct {
+		Title  string
+		Page   string
+		Out    string
+		OutErr error
+	}{
+		{"A", "/empty", "[1]", errorNoResource},
+		{"B", "/cycle", "[]", errorPageCycle},
+		{"C", "/loop1", "[2 2]", errorPageCycle},
+	}
+	for _, v := range data {
+		it, err := ResumePageIterator(context.Background(), c, v.Page, rel)
+		if err != nil {
+			t.Fatal("for test", v.Title, "expected no error got", err)
+		}
+		if out := fmt.Sprint(collect(it)); out != v.Out {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+		if it.Err() != v.OutErr {
+			t.Error("for test", v.Title, "expected", v.OutErr, "got", it.Err())
+		}
+	}
+}
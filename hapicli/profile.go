@@ -0,0 +1,101 @@
+package hapicli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ritoon/hapiclient-go/hapicli/alps"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+var errorNoProfile = errors.New("Hapicli: the link has no profile")
+
+// Profile returns the ALPS profile of the link, fetched from the URL
+// of its profile property the first time and then kept by the client.
+// throws *ErrResponse
+func (c *Client) Profile(ctx context.Context, l *hal.Link) (*alps.Profile, error) {
+	if l.Profile() == "" {
+		return nil, errorNoProfile
+	}
+	href := l.Profile()
+	if i := strings.Index(href, "#"); i >= 0 {
+		href = href[:i]
+	}
+	u, err := c.resolve(href)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	p, ok := c.profiles[u]
+	c.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+	h := make(http.Header)
+	h.Set("Accept", "application/alps+json, application/alps+xml, application/json, application/xml")
+	get := &Request{method: "GET"}
+	_, body, err := c.do(ctx, &call{url: u, request: get, rel: "profile", header: h})
+	if err != nil {
+		return nil, err
+	}
+	if p, err = alps.Parse(body); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.profiles == nil {
+		c.profiles = make(map[string]*alps.Profile)
+	}
+	c.profiles[u] = p
+	c.mu.Unlock()
+	return p, nil
+}
+
+// ProfileDescriptor returns the descriptor of the link: the descriptor
+// of its profile whose id is the fragment of the profile URL, e.g. the
+// create-orders descriptor of https://api.example.com/alps#create-orders.
+// It tells which methods and fields the link expects.
+// throws alps.ErrDescriptorNotFound
+// throws *ErrResponse
+func (c *Client) ProfileDescriptor(ctx context.Context, l *hal.Link) (*alps.Descriptor, error) {
+	p, err := c.Profile(ctx, l)
+	if err != nil {
+		return nil, err
+	}
+	i := strings.Index(l.Profile(), "#")
+	if i < 0 {
+		return nil, alps.ErrDescriptorNotFound
+	}
+	return p.Descriptor(l.Profile()[i+1:])
+}
+
+// validateRequest checks the request sent on the link of rel against
+// the descriptor of the link, or the descriptor named after the rel
+// when the profile URL has no fragment.
+// throws *ErrInvalidRequest
+func (c *Client) validateRequest(ctx context.Context, rel string, l *hal.Link, r AbstractRequester) error {
+	p, err := c.Profile(ctx, l)
+	if err != nil {
+		c.logger.Warn("hapicli profile unavailable", "rel", rel, "profile", l.Profile(), "error", err)
+		return nil
+	}
+	id := rel
+	if i := strings.LastIndexAny(id, ":#/"); i >= 0 {
+		id = id[i+1:]
+	}
+	if i := strings.Index(l.Profile(), "#"); i >= 0 {
+		id = l.Profile()[i+1:]
+	}
+	d, err := p.Descriptor(id)
+	if err != nil {
+		c.logger.Warn("hapicli profile has no descriptor for the link", "rel", rel, "profile", l.Profile())
+		return nil
+	}
+	if problems := d.ValidateRequest(r.Method(), []byte(r.MessageBody())); len(problems) > 0 {
+		return &ErrInvalidRequest{Rel: rel, Method: r.Method(), Problems: problems}
+	}
+	return nil
+}
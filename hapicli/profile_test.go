@@ -0,0 +1,133 @@
+package hapicli
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestProfile(t *testing.T) {
+	doc, _ := ioutil.ReadFile("alps/testdata/orders.json")
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/alps" {
+			http.NotFound(w, r)
+			return
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/alps+json")
+		w.Write(doc)
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	data := []struct {
+		Title      string
+		Profile    string
+		OutMethods []string
+		OutErr     bool
+	}{
+		{"A", "/alps#create-orders", []string{"POST", "PATCH"}, false},
+		{"B", srv.URL + "/alps#update-orders", []string{"PUT", "DELETE"}, false},
+		{"C", "/alps", nil, true},
+		{"D", "/alps#delete-orders", nil, true},
+		{"E", "", nil, true},
+		{"F", "/missing#create-orders", nil, true},
+	}
+	for _, v := range data {
+		l, _ := hal.NewLink("/orders", hal.LinkOptionalParam{Profile: v.Profile})
+		d, err := c.ProfileDescriptor(context.Background(), l)
+		if (err != nil) != v.OutErr {
+			t.Error("for test", v.Title, "expected an error", v.OutErr, "got", err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(d.Methods(), v.OutMethods) {
+			t.Error("for test", v.Title, "expected", v.OutMethods, "got", d.Methods())
+		}
+	}
+	if calls != 1 {
+		t.Error("expected the profile to be fetched once got", calls)
+	}
+
+	l, _ := hal.NewLink("/orders", hal.LinkOptionalParam{Profile: "/alps#create-orders"})
+	d, _ := c.ProfileDescriptor(context.Background(), l)
+	if f, err := d.Field("reference"); err != nil || f.ID != "ref" {
+		t.Error("expected the reference field got", f, err)
+	}
+}
+
+func TestSetProfileValidation(t *testing.T) {
+	doc, _ := ioutil.ReadFile("alps/testdata/orders.json")
+	sent := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/alps":
+			w.Header().Set("Content-Type", "application/alps+json")
+			w.Write(doc)
+		case "/":
+			w.Header().Set("Content-Type", "application/hal+json")
+			w.Write([]byte(`{"_links":{
+				"self":{"href":"/"},
+				"orders":{"href":"/orders","profile":"/alps#create-orders"},
+				"get-orders":{"href":"/orders","profile":"/alps"},
+				"customers":{"href":"/customers","profile":"/missing"}
+			}}`))
+		case "/orders", "/customers":
+			sent++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	data := []struct {
+		Title    string
+		Validate bool
+		Rel      string
+		Method   string
+		Body     string
+		OutErr   []string
+	}{
+		{"A", true, "orders", "POST", `{"amount":100,"reference":"r1"}`, nil},
+		{"B", true, "orders", "POST", `{"amount":100,"color":"red"}`, []string{"/color: is not described by create-orders"}},
+		{"C", true, "orders", "GET", ``, []string{"the method GET is not allowed by the unsafe descriptor create-orders"}},
+		{"D", false, "orders", "GET", ``, nil},
+		{"E", true, "get-orders", "GET", ``, nil},
+		{"F", true, "get-orders", "POST", `{}`, []string{"the method POST is not allowed by the safe descriptor get-orders"}},
+		{"G", true, "customers", "POST", `{"any":1}`, nil},
+	}
+	for _, v := range data {
+		c := NewClient(nil)
+		c.SetAPIURL(srv.URL)
+		c.SetLogger(&recordLogger{})
+		c.SetProfileValidation(v.Validate)
+		r, _ := NewRequest(v.Method, nil, v.Body, "")
+		rel, _ := hal.NewCustomRel(v.Rel)
+		before := sent
+		_, err := c.SendFollow(context.Background(), nil, NewFollow(rel, r))
+		if v.OutErr == nil {
+			if err != nil || sent != before+1 {
+				t.Error("for test", v.Title, "expected the request to be sent got", err)
+			}
+			continue
+		}
+		e, ok := err.(*ErrInvalidRequest)
+		if !ok {
+			t.Error("for test", v.Title, "expected an *ErrInvalidRequest got", err)
+			continue
+		}
+		if e.Rel != v.Rel || e.Method != v.Method || !reflect.DeepEqual(e.Problems, v.OutErr) {
+			t.Error("for test", v.Title, "expected", v.OutErr, "got", e)
+		}
+		if sent != before {
+			t.Error("for test", v.Title, "expected the request not to be sent")
+		}
+	}
+}
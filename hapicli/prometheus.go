@@ -0,0 +1,160 @@
+package hapicli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The upper bounds of the buckets of the durations, in seconds.
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics is a Metrics collector exposing its measures in the
+// Prometheus text exposition format: it is an http.Handler to be
+// served on the metrics path of the application.
+// see https://prometheus.io/docs/instrumenting/exposition_formats/
+type PrometheusMetrics struct {
+	mu        sync.Mutex
+	requests  map[string]uint64
+	durations map[string]*histogram
+	retries   map[string]uint64
+	cache     map[string]uint64
+	inFlight  int64
+}
+
+// histogram counts the observations by bucket.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusMetrics create a PrometheusMetrics
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		requests:  make(map[string]uint64),
+		durations: make(map[string]*histogram),
+		retries:   make(map[string]uint64),
+		cache:     make(map[string]uint64),
+	}
+}
+
+func (m *PrometheusMetrics) RequestStarted(method, rel string) {
+	m.mu.Lock()
+	m.inFlight++
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) RequestDone(method, rel, statusClass string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight--
+	m.requests[labels("method", method, "rel", rel, "status_class", statusClass)]++
+
+	key := labels("method", method, "rel", rel)
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		m.durations[key] = h
+	}
+	s := d.Seconds()
+	for i, b := range durationBuckets {
+		if s <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += s
+	h.count++
+}
+
+func (m *PrometheusMetrics) Retried(method, rel string) {
+	m.mu.Lock()
+	m.retries[labels("method", method, "rel", rel)]++
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) Cached(method, rel string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.mu.Lock()
+	m.cache[labels("method", method, "rel", rel, "result", result)]++
+	m.mu.Unlock()
+}
+
+// ServeHTTP writes the metrics in the text exposition format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the text exposition format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b bytes.Buffer
+	writeCounter(&b, "hapicli_requests_total", "The number of requests by method, rel and status class.", m.requests)
+
+	b.WriteString("# HELP hapicli_request_duration_seconds The duration of the requests by method and rel.\n")
+	b.WriteString("# TYPE hapicli_request_duration_seconds histogram\n")
+	for _, key := range sortedKeys(m.durations) {
+		h := m.durations[key]
+		for i, bound := range durationBuckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(&b, "hapicli_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", key, le, h.counts[i])
+		}
+		fmt.Fprintf(&b, "hapicli_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key, h.count)
+		fmt.Fprintf(&b, "hapicli_request_duration_seconds_sum{%s} %g\n", key, h.sum)
+		fmt.Fprintf(&b, "hapicli_request_duration_seconds_count{%s} %d\n", key, h.count)
+	}
+
+	writeCounter(&b, "hapicli_retries_total", "The number of requests sent again by method and rel.", m.retries)
+	writeCounter(&b, "hapicli_cache_responses_total", "The number of responses by method, rel and cache result (hit or miss).", m.cache)
+
+	b.WriteString("# HELP hapicli_in_flight_requests The number of requests waiting for their response.\n")
+	b.WriteString("# TYPE hapicli_in_flight_requests gauge\n")
+	fmt.Fprintf(&b, "hapicli_in_flight_requests %d\n", m.inFlight)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeCounter writes a counter with one sample per set of labels.
+func writeCounter(b *bytes.Buffer, name, help string, samples map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(samples))
+	for k := range samples {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s} %d\n", name, k, samples[k])
+	}
+}
+
+func sortedKeys(m map[string]*histogram) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labels formats the alternating names and values as `name="value",...`.
+func labels(kv ...string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	s := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		s = append(s, kv[i]+`="`+r.Replace(kv[i+1])+`"`)
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,68 @@
+package hapicli
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	srv := newCollectionServer(2, 1)
+	defer srv.Close()
+	failures := 0
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" && failures == 0 {
+			failures++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/" {
+			w.Header().Set("Age", "12")
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	m := NewPrometheusMetrics()
+	c := NewClient(nil)
+	c.SetAPIURL(flaky.URL)
+	c.SetMetrics(m)
+	c.SetMaxRetries(1)
+	c.retryDelay = time.Millisecond
+	orders, _ := hal.NewCustomRel("ex:orders")
+	unknown, _ := hal.NewCustomRel("ex:unknown")
+	c.SendFollow(context.Background(), nil, NewFollow(orders, nil), NewFollow(hal.NEXT, nil))
+	c.Send(context.Background(), "/unknown", &Request{method: "GET"})
+	c.SendFollow(context.Background(), nil, NewFollow(unknown, nil))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out, _ := ioutil.ReadAll(rec.Body)
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Error("expected the text exposition format got", rec.Header().Get("Content-Type"))
+	}
+	for _, line := range []string{
+		`hapicli_requests_total{method="GET",rel="",status_class="2xx"} 2`,
+		`hapicli_requests_total{method="GET",rel="",status_class="4xx"} 1`,
+		`hapicli_requests_total{method="GET",rel="ex:orders",status_class="2xx"} 1`,
+		`hapicli_requests_total{method="GET",rel="next",status_class="2xx"} 1`,
+		`hapicli_requests_total{method="GET",rel="next",status_class="5xx"} 1`,
+		`hapicli_request_duration_seconds_bucket{method="GET",rel="next",le="+Inf"} 2`,
+		`hapicli_request_duration_seconds_count{method="GET",rel="ex:orders"} 1`,
+		`hapicli_retries_total{method="GET",rel="next"} 1`,
+		`hapicli_cache_responses_total{method="GET",rel="",result="hit"} 2`,
+		`hapicli_cache_responses_total{method="GET",rel="",result="miss"} 1`,
+		`hapicli_in_flight_requests 0`,
+		`# TYPE hapicli_request_duration_seconds histogram`,
+	} {
+		if !strings.Contains(string(out), line+"\n") {
+			t.Error("expected the line", line, "in", string(out))
+		}
+	}
+}
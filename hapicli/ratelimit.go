@@ -0,0 +1,43 @@
+package hapicli
+
+import (
+	"context"
+	"time"
+)
+
+// SetRateLimit is setting the maximum number of requests per second
+// sent by the client, retries included, whatever the goroutines
+// sending them, no limit when 0
+func (c *Client) SetRateLimit(perSecond float64) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	c.interval = 0
+	if perSecond > 0 {
+		c.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+}
+
+// wait waits for the turn of the next request under the rate limit.
+func (c *Client) wait(ctx context.Context) error {
+	c.rateMu.Lock()
+	if c.interval <= 0 {
+		c.rateMu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	at := c.nextRequest
+	if at.Before(now) {
+		at = now
+	}
+	c.nextRequest = at.Add(c.interval)
+	c.rateMu.Unlock()
+	if at.Equal(now) {
+		return nil
+	}
+	select {
+	case <-time.After(at.Sub(now)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
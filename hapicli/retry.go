@@ -0,0 +1,41 @@
+package hapicli
+
+import (
+	"net/http"
+	"time"
+)
+
+// The default delay before sending a request again,
+// multiplied by the number of the attempt.
+const defaultRetryDelay = 100 * time.Millisecond
+
+// SetMaxRetries is setting the number of times an idempotent request
+// (GET, PUT or DELETE) is sent again after a network error or a
+// 502, 503 or 504 response, none by default
+func (c *Client) SetMaxRetries(n int) {
+	c.maxRetries = n
+}
+
+// retry tells if the call must be sent again after the given outcome.
+func (c *Client) retry(cl *call, resp *http.Response, err error) bool {
+	if cl.attempt > c.maxRetries || !isIdempotent(cl.request.Method()) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isIdempotent tells if the method can be sent again safely.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
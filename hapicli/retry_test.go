@@ -0,0 +1,52 @@
+package hapicli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	data := []struct {
+		Title      string
+		Method     string
+		MaxRetries int
+		OutCalls   int
+		OutErr     bool
+	}{
+		{"A", "GET", 0, 1, true},
+		{"B", "GET", 1, 2, true},
+		{"C", "GET", 5, 3, false},
+		{"D", "POST", 5, 1, true},
+	}
+	for _, v := range data {
+		calls = 0
+		l := &recordLogger{}
+		c := NewClient(nil)
+		c.SetLogger(l)
+		c.SetMaxRetries(v.MaxRetries)
+		c.retryDelay = time.Millisecond
+		r, _ := NewRequest(v.Method, nil, "", "")
+		_, err := c.Send(context.Background(), srv.URL, r)
+		if (err != nil) != v.OutErr || calls != v.OutCalls {
+			t.Error("for test", v.Title, "expected", v.OutCalls, "calls got", calls, err)
+		}
+		if last := l.debugs[len(l.debugs)-1]; arg(last, "attempt") != v.OutCalls {
+			t.Error("for test", v.Title, "expected attempt", v.OutCalls, "got", arg(last, "attempt"))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package siren reads the Siren documents into the resources of the hal
+// package, so the same navigation code works on Siren and HAL APIs:
+// the sub-entities become embedded resources, the links and the embedded
+// links become links of each of their rels and the actions become
+// HAL-FORMS templates.
+// see https://github.com/kevinswiber/siren
+package siren
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// MediaType is the content type of the Siren documents.
+const MediaType = "application/vnd.siren+json"
+
+var errorNoRel = errors.New("Siren: a sub-entity must have a rel")
+
+// entity is a Siren entity, or a sub-entity when it has rels.
+type entity struct {
+	Class      []string               `json:"class"`
+	Rel        []string               `json:"rel"`
+	Href       string                 `json:"href"`
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Properties map[string]interface{} `json:"properties"`
+	Entities   []entity               `json:"entities"`
+	Links      []link                 `json:"links"`
+	Actions    []action               `json:"actions"`
+}
+
+type link struct {
+	Class []string `json:"class"`
+	Rel   []string `json:"rel"`
+	Href  string   `json:"href"`
+	Title string   `json:"title"`
+	Type  string   `json:"type"`
+}
+
+type action struct {
+	Name   string   `json:"name"`
+	Class  []string `json:"class"`
+	Method string   `json:"method"`
+	Href   string   `json:"href"`
+	Title  string   `json:"title"`
+	Type   string   `json:"type"`
+	Fields []field  `json:"fields"`
+}
+
+type field struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+	Title string      `json:"title"`
+}
+
+// Decode builds the resource of a Siren entity.
+// The properties of the entity are the state of the resource, its class
+// and title are kept as the class and title properties when the
+// properties do not have them.
+// An action is a template whose key is the name of the action, GET and
+// form encoded by default as described by Siren.
+// throws errorNoRel
+func Decode(data []byte) (*hal.Resource, error) {
+	var e entity
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("Siren: the document must be a JSON object : %v", err)
+	}
+	return e.resource()
+}
+
+// resource builds the resource of the entity.
+func (e *entity) resource() (*hal.Resource, error) {
+	r := new(hal.Resource)
+	for k, v := range e.Properties {
+		r.SetProperty(k, v)
+	}
+	if _, ok := e.Properties["class"]; !ok && len(e.Class) > 0 {
+		r.SetProperty("class", e.Class)
+	}
+	if _, ok := e.Properties["title"]; !ok && e.Title != "" {
+		r.SetProperty("title", e.Title)
+	}
+	for _, l := range e.Links {
+		if err := addLink(r, l.Rel, l.Href, hal.LinkOptionalParam{MediaType: l.Type, Title: l.Title}); err != nil {
+			return nil, err
+		}
+	}
+	for _, sub := range e.Entities {
+		if len(sub.Rel) == 0 {
+			return nil, errorNoRel
+		}
+		// an embedded link
+		if sub.Href != "" && sub.Properties == nil && sub.Entities == nil && sub.Links == nil && sub.Actions == nil {
+			if err := addLink(r, sub.Rel, sub.Href, hal.LinkOptionalParam{MediaType: sub.Type, Title: sub.Title}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		er, err := sub.resource()
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range sub.Rel {
+			r.AddEmbeddedResource(rel, er)
+		}
+	}
+	for _, a := range e.Actions {
+		r.AddTemplate(a.Name, a.template())
+	}
+	return r, nil
+}
+
+// addLink adds the link with each of the rels.
+func addLink(r *hal.Resource, rels []string, href string, lop hal.LinkOptionalParam) error {
+	l, err := hal.NewLink(href, lop)
+	if err != nil {
+		return err
+	}
+	for _, rel := range rels {
+		r.AddLink(rel, *l)
+	}
+	return nil
+}
+
+// template returns the HAL-FORMS template of the action.
+func (a *action) template() *hal.Template {
+	t := &hal.Template{Title: a.Title, Method: a.Method, ContentType: a.Type, Target: a.Href}
+	if t.Method == "" {
+		t.Method = "GET"
+	}
+	if t.ContentType == "" {
+		t.ContentType = "application/x-www-form-urlencoded"
+	}
+	for _, f := range a.Fields {
+		t.Properties = append(t.Properties, hal.Property{
+			Name:   f.Name,
+			Prompt: f.Title,
+			Type:   f.Type,
+			Value:  f.Value,
+		})
+	}
+	return t
+}
@@ -0,0 +1,85 @@
+package siren
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestDecode(t *testing.T) {
+	data, _ := ioutil.ReadFile("testdata/order.json")
+	r, err := Decode(data)
+	if err != nil {
+		t.Fatal("expected no error got", err)
+	}
+	if r.State()["orderNumber"] != 42.0 || !reflect.DeepEqual(r.State()["class"], []string{"order"}) {
+		t.Error("expected the properties and the class got", r.State())
+	}
+
+	links := []struct {
+		Title string
+		Rel   string
+		Href  string
+	}{
+		{"A", "self", "http://api.x.io/orders/42"},
+		{"B", "next", "http://api.x.io/orders/43"},
+		{"C", "http://x.io/rels/order-items", "http://api.x.io/orders/42/items"},
+	}
+	for _, v := range links {
+		l, err := r.Link(v.Rel)
+		if err != nil || l.Href() != v.Href {
+			t.Error("for test", v.Title, "expected", v.Href, "got", l, err)
+		}
+	}
+
+	customer, err := r.EmbeddedResource("http://x.io/rels/customer")
+	if err != nil {
+		t.Fatal("expected the customer got", err)
+	}
+	if self, _ := customer.Link("self"); self == nil || self.Href() != "http://api.x.io/customers/pj123" || customer.State()["name"] != "Peter Joseph" {
+		t.Error("expected the customer resource got", customer.State())
+	}
+
+	add, err := r.Template("add-item")
+	if err != nil {
+		t.Fatal("expected the add-item template got", err)
+	}
+	if add.Method != "POST" || add.Target != "http://api.x.io/orders/42/items" || add.ContentType != "application/x-www-form-urlencoded" {
+		t.Error("expected the add-item template got", add)
+	}
+	if !reflect.DeepEqual(add.Properties[0], hal.Property{Name: "orderNumber", Type: "hidden", Value: "42"}) || len(add.Properties) != 3 {
+		t.Error("expected the fields as properties got", add.Properties)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	data := []struct {
+		Title string
+		In    string
+		Out   []string
+	}{
+		{"A", `{"actions":[{"name":"search","href":"/search"}]}`, []string{"GET", "application/x-www-form-urlencoded"}},
+		{"B", `{"entities":[{"href":"/a"}]}`, nil},
+		{"C", `[]`, nil},
+		{"D", `{"links":[{"rel":["self"],"href":" "}]}`, nil},
+	}
+	for _, v := range data {
+		r, err := Decode([]byte(v.In))
+		if v.Out == nil {
+			if err == nil {
+				t.Error("for test", v.Title, "expected an error")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error("for test", v.Title, "expected no error got", err)
+			continue
+		}
+		tpl, _ := r.Template("search")
+		if out := []string{tpl.Method, tpl.ContentType}; !reflect.DeepEqual(out, v.Out) {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+}
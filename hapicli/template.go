@@ -0,0 +1,152 @@
+package hapicli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ritoon/hapiclient-go/hapicli/collectionjson"
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+var errorTemplateContentType = errors.New("Hapicli: the content type of the template must be JSON or form encoded")
+
+// SubmitTemplate sends the request described by the HAL-FORMS template of
+// the resource, filled with the values and the default values of its
+// properties. The values are checked against the properties before the
+// request is sent, the options given by a link being fetched first.
+// - param key		string					The key of the template, hal.DefaultTemplate for a single one
+// - param values	map[string]interface{}	The values by property name
+//
+// The body is JSON, or form encoded when the content type of the template
+// is application/x-www-form-urlencoded, or a Collection+JSON template
+// document when it is application/vnd.collection+json. The values of a
+// GET template are sent in the query.
+// throws hal.ErrTemplateNotFound
+// throws *ErrInvalidTemplate
+// throws *ErrResponse
+func (c *Client) SubmitTemplate(ctx context.Context, res *hal.Resource, key string, values map[string]interface{}) (*hal.Resource, error) {
+	t, err := res.Template(key)
+	if err != nil {
+		return nil, err
+	}
+	if t, err = c.templateOptions(ctx, t); err != nil {
+		return nil, err
+	}
+	values = t.Values(values)
+	if problems := t.Validate(values); len(problems) > 0 {
+		return nil, &ErrInvalidTemplate{Template: key, Problems: problems}
+	}
+
+	target := t.Target
+	if target == "" {
+		self, err := res.Link(hal.SELF.Name())
+		if err != nil {
+			return nil, errorNoSelf
+		}
+		target = self.Href()
+	}
+	method := strings.ToUpper(t.Method)
+	if method == "GET" || method == "HEAD" {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		for k, vs := range formValues(values) {
+			q[k] = append(q[k], vs...)
+		}
+		u.RawQuery = q.Encode()
+		return c.send(ctx, &call{url: u.String(), request: &Request{method: method}})
+	}
+
+	ct := t.ContentType
+	if ct == "" {
+		ct = "application/json"
+	}
+	mt, _, _ := mime.ParseMediaType(ct)
+	var body string
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		body = formValues(values).Encode()
+	case mt == collectionjson.MediaType:
+		b, err := collectionjson.TemplateBody(values)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		b, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+	default:
+		return nil, errorTemplateContentType
+	}
+	h := make(http.Header)
+	h.Set("Content-Type", ct)
+	return c.send(ctx, &call{url: target, request: &Request{method: method, messageBody: body}, header: h})
+}
+
+// templateOptions returns the template with the options of its
+// properties given by a link fetched, or t itself if there are none.
+func (c *Client) templateOptions(ctx context.Context, t *hal.Template) (*hal.Template, error) {
+	var out *hal.Template
+	for i, p := range t.Properties {
+		o := p.Options
+		if o == nil || o.Link == nil || len(o.Inline) > 0 || o.Link.Templated() {
+			continue
+		}
+		if out == nil {
+			clone := *t
+			clone.Properties = append([]hal.Property(nil), t.Properties...)
+			out = &clone
+		}
+		accept := o.Link.Type()
+		if accept == "" {
+			accept = "application/json"
+		}
+		h := make(http.Header)
+		h.Set("Accept", accept)
+		_, body, err := c.do(ctx, &call{url: o.Link.Href(), request: &Request{method: "GET"}, link: o.Link, header: h})
+		if err != nil {
+			return nil, err
+		}
+		inline, err := o.ParseValues(body)
+		if err != nil {
+			return nil, err
+		}
+		linked := *o
+		linked.Inline = inline
+		out.Properties[i].Options = &linked
+	}
+	if out == nil {
+		return t, nil
+	}
+	return out, nil
+}
+
+// formValues returns the values as form values,
+// a slice giving several values.
+func formValues(values map[string]interface{}) url.Values {
+	out := make(url.Values, len(values))
+	for k, v := range values {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, item := range v {
+				out.Add(k, fmt.Sprint(item))
+			}
+		case []string:
+			out[k] = append(out[k], v...)
+		default:
+			out.Set(k, fmt.Sprint(v))
+		}
+	}
+	return out
+}
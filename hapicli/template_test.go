@@ -0,0 +1,112 @@
+package hapicli
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+func TestSubmitTemplate(t *testing.T) {
+	type received struct {
+		method, uri, contentType, body string
+	}
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/countries" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"name":"France","code":"FR"},{"name":"Spain","code":"ES"}]`))
+			return
+		}
+		got = append(got, received{r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type"), string(body)})
+		w.Header().Set("Content-Type", "application/prs.hal-forms+json")
+		w.Write([]byte(`{"_links":{"self":{"href":"/orders/1"}},"status":"done"}`))
+	}))
+	defer srv.Close()
+
+	min := 1.0
+	res := hal.NewBuilder().
+		Self("/orders/1").
+		Template(hal.DefaultTemplate, &hal.Template{Method: "put", Properties: []hal.Property{
+			{Name: "reference", Required: true, Value: "ORD-1"},
+			{Name: "amount", Min: &min},
+			{Name: "country", Options: &hal.Options{
+				Link:        mustLink(srv.URL + "/countries"),
+				PromptField: "name",
+				ValueField:  "code",
+			}},
+		}}).
+		Template("search", &hal.Template{Method: "GET", Target: "/orders?sort=asc", Properties: []hal.Property{{Name: "q"}}}).
+		Template("form", &hal.Template{Method: "POST", ContentType: "application/x-www-form-urlencoded", Properties: []hal.Property{{Name: "tags"}}}).
+		Template("xml", &hal.Template{Method: "POST", ContentType: "application/xml"}).
+		Template("cj", &hal.Template{Method: "POST", ContentType: "application/vnd.collection+json", Target: "/friends/"}).
+		MustBuild()
+
+	c := NewClient(nil)
+	c.SetAPIURL(srv.URL)
+	data := []struct {
+		Title  string
+		Key    string
+		Values map[string]interface{}
+		Out    received
+		OutErr interface{}
+	}{
+		{"A", hal.DefaultTemplate, map[string]interface{}{"amount": 10, "country": "FR"}, received{"PUT", "/orders/1", "application/json", `{"amount":10,"country":"FR","reference":"ORD-1"}`}, nil},
+		{"B", hal.DefaultTemplate, map[string]interface{}{"amount": 0, "country": "UK", "reference": ""}, received{}, []string{
+			"reference: is required",
+			"amount: must be at least 1",
+			"country: UK is not one of the options",
+		}},
+		{"C", "search", map[string]interface{}{"q": "shoes"}, received{"GET", "/orders?q=shoes&sort=asc", "", ""}, nil},
+		{"D", "form", map[string]interface{}{"tags": []interface{}{"a", "b"}}, received{"POST", "/orders/1", "application/x-www-form-urlencoded", "tags=a&tags=b"}, nil},
+		{"E", "xml", nil, received{}, errorTemplateContentType},
+		{"F", "unknown", nil, received{}, hal.ErrTemplateNotFound},
+		{"G", "cj", map[string]interface{}{"email": "a@example.org"}, received{"POST", "/friends/", "application/vnd.collection+json", `{"template":{"data":[{"name":"email","value":"a@example.org"}]}}`}, nil},
+	}
+	for _, v := range data {
+		got = nil
+		out, err := c.SubmitTemplate(context.Background(), res, v.Key, v.Values)
+		if problems, ok := v.OutErr.([]string); ok {
+			e, isInvalid := err.(*ErrInvalidTemplate)
+			if !isInvalid || e.Template != v.Key || !reflect.DeepEqual(e.Problems, problems) {
+				t.Error("for test", v.Title, "expected", problems, "got", err)
+			}
+		} else if v.OutErr != nil && err != v.OutErr {
+			t.Error("for test", v.Title, "expected", v.OutErr, "got", err)
+		}
+		if v.OutErr != nil {
+			if len(got) != 0 {
+				t.Error("for test", v.Title, "expected no request got", got)
+			}
+			continue
+		}
+		if err != nil || out.State()["status"] != "done" {
+			t.Error("for test", v.Title, "expected the response resource got", out, err)
+			continue
+		}
+		if len(got) != 1 || !reflect.DeepEqual(got[0], v.Out) {
+			t.Error("for test", v.Title, "expected", v.Out, "got", got)
+		}
+	}
+
+	// the linked options are fetched on a copy of the template
+	tpl, _ := res.Template(hal.DefaultTemplate)
+	if len(tpl.Property("country").Options.Inline) != 0 {
+		b, _ := json.Marshal(tpl)
+		t.Error("expected the template of the resource unchanged got", string(b))
+	}
+}
+
+func mustLink(href string) *hal.Link {
+	l, err := hal.NewLink(href, hal.LinkOptionalParam{})
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
@@ -0,0 +1,59 @@
+package hapicli
+
+import (
+	"context"
+)
+
+// Tracer opens the spans of the client, it is meant to be adapted to a
+// tracing library such as OpenTelemetry.
+//
+// A span is opened for each HTTP request, as a child of the span of the
+// context. SendFollow opens a parent span for the whole chain of follows.
+type Tracer interface {
+	// Start opens a span, child of the span of ctx if any, and
+	// returns the context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a unit of work traced by the client.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// TraceParent returns the W3C traceparent header identifying the span,
+	// it is sent with the requests. No header is sent when empty.
+	// see https://www.w3.org/TR/trace-context/#traceparent-header
+	TraceParent() string
+	// End closes the span with the error of the work, if any.
+	End(err error)
+}
+
+// The attributes of the spans.
+const (
+	AttrRel        = "hal.rel"
+	AttrRels       = "hal.rels"
+	AttrLinkName   = "hal.link.name"
+	AttrTemplated  = "hal.link.templated"
+	AttrMethod     = "http.method"
+	AttrURL        = "http.url"
+	AttrStatusCode = "http.status_code"
+	AttrAttempt    = "http.attempt"
+)
+
+// SetTracer is setting the tracer of the client, no span is opened when nil
+func (c *Client) SetTracer(t Tracer) {
+	c.tracer = t
+}
+
+// startSpan opens a span with the tracer of the client.
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return c.tracer.Start(ctx, name)
+}
+
+// noopSpan is the span used when the client has no tracer.
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) TraceParent() string                        { return "" }
+func (noopSpan) End(err error)                              {}
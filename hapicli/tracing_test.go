@@ -0,0 +1,113 @@
+package hapicli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ritoon/hapiclient-go/hapicli/hal"
+)
+
+// recordSpan is a span kept in memory by the recordTracer.
+type recordSpan struct {
+	name    string
+	traceID string
+	spanID  string
+	parent  *recordSpan
+	attrs   map[string]interface{}
+	ended   bool
+	err     error
+}
+
+func (s *recordSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *recordSpan) TraceParent() string {
+	return "00-" + s.traceID + "-" + s.spanID + "-01"
+}
+
+func (s *recordSpan) End(err error) {
+	s.ended, s.err = true, err
+}
+
+type spanKey struct{}
+
+// recordTracer is an in-memory Tracer.
+type recordTracer struct {
+	mu    sync.Mutex
+	spans []*recordSpan
+}
+
+func (t *recordTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &recordSpan{name: name, attrs: make(map[string]interface{})}
+	s.spanID = fmt.Sprintf("%016x", len(t.spans)+1)
+	s.traceID = fmt.Sprintf("%032x", len(t.spans)+1)
+	if p, ok := ctx.Value(spanKey{}).(*recordSpan); ok {
+		s.parent, s.traceID = p, p.traceID
+	}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func TestTracer(t *testing.T) {
+	var traceparents []string
+	srv := newCollectionServer(3, 2)
+	defer srv.Close()
+	tracing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer tracing.Close()
+
+	tr := &recordTracer{}
+	c := NewClient(nil)
+	c.SetAPIURL(tracing.URL)
+	c.SetTracer(tr)
+	orders, _ := hal.NewCustomRel("ex:orders")
+	page2, _ := NewRequest("GET", []string{"page=2"}, "", "")
+	if _, err := c.SendFollow(context.Background(), nil, NewFollow(orders, page2), NewFollow(hal.NEXT, nil)); err != nil {
+		t.Fatal("expected no error got", err)
+	}
+
+	if len(tr.spans) != 4 {
+		t.Fatal("expected a follow span and 3 request spans got", len(tr.spans))
+	}
+	root := tr.spans[0]
+	if root.name != "hapicli.follow" || root.parent != nil || !root.ended {
+		t.Error("expected an ended root follow span got", root)
+	}
+	if fmt.Sprint(root.attrs[AttrRels]) != "[ex:orders next]" {
+		t.Error("expected the followed rels got", root.attrs[AttrRels])
+	}
+	data := []struct {
+		Title     string
+		Rel       interface{}
+		Templated interface{}
+		URL       string
+	}{
+		{"A", nil, nil, tracing.URL},
+		{"B", "ex:orders", true, tracing.URL + "/orders?page=2"},
+		{"C", "next", false, tracing.URL + "/orders?page=3"},
+	}
+	for i, v := range data {
+		s := tr.spans[i+1]
+		if s.parent != root || s.name != "HTTP GET" || !s.ended {
+			t.Error("for test", v.Title, "expected an ended child span got", s)
+		}
+		if s.attrs[AttrRel] != v.Rel || s.attrs[AttrTemplated] != v.Templated {
+			t.Error("for test", v.Title, "expected rel", v.Rel, "templated", v.Templated, "got", s.attrs)
+		}
+		if s.attrs[AttrURL] != v.URL || s.attrs[AttrStatusCode] != 200 {
+			t.Error("for test", v.Title, "expected", v.URL, "200 got", s.attrs)
+		}
+		if traceparents[i] != s.TraceParent() {
+			t.Error("for test", v.Title, "expected traceparent", s.TraceParent(), "got", traceparents[i])
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package hapicli
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// urlVariables reads the url variables of a request,
+// each of them being a "name=value" pair.
+func urlVariables(vs []string) map[string]string {
+	m := make(map[string]string)
+	for _, v := range vs {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			continue
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
+
+// expandURI expands the URI Template tpl with the given variables
+// as described in the RFC6570 (level 3, the prefix and explode
+// modifiers are accepted but ignored). Undefined variables are omitted.
+// see https://tools.ietf.org/html/rfc6570
+func expandURI(tpl string, vars map[string]string) string {
+	var out []string
+	for {
+		start := strings.Index(tpl, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(tpl[start:], "}")
+		if end < 0 {
+			break
+		}
+		out = append(out, tpl[:start], expandExpression(tpl[start+1:start+end], vars))
+		tpl = tpl[start+end+1:]
+	}
+	out = append(out, tpl)
+	return strings.Join(out, "")
+}
+
+// expandExpression expands the content of a {...} expression.
+func expandExpression(exp string, vars map[string]string) string {
+	var op string
+	if len(exp) > 0 && strings.ContainsRune("+#./;?&", rune(exp[0])) {
+		op, exp = exp[:1], exp[1:]
+	}
+	first, sep, named, reserved := op, ",", false, false
+	switch op {
+	case "+":
+		first, reserved = "", true
+	case "#":
+		reserved = true
+	case ".", "/":
+		sep = op
+	case ";":
+		sep, named = ";", true
+	case "?", "&":
+		sep, named = "&", true
+	}
+
+	var parts []string
+	for _, name := range strings.Split(exp, ",") {
+		name = strings.TrimRight(name, "*")
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[:i]
+		}
+		v, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if reserved {
+			v = escapeReserved(v)
+		} else {
+			v = escapeUnreserved(v)
+		}
+		switch {
+		case named && len(v) == 0 && op == ";":
+			parts = append(parts, name)
+		case named:
+			parts = append(parts, name+"="+v)
+		default:
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return first + strings.Join(parts, sep)
+}
+
+// escapeUnreserved percent-encodes everything but the unreserved characters.
+func escapeUnreserved(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// escapeReserved percent-encodes everything but the
+// unreserved and reserved characters.
+func escapeReserved(s string) string {
+	var b bytes.Buffer
+	for _, c := range []byte(s) {
+		if c < 0x80 && (isUnreservedByte(c) || strings.IndexByte(":/?#[]@!$&'()*+,;=%", c) >= 0) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func isUnreservedByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0
+}
@@ -0,0 +1,43 @@
+package hapicli
+
+import (
+	"testing"
+)
+
+func TestExpandURI(t *testing.T) {
+	vars := map[string]string{
+		"id":    "42",
+		"q":     "hello world",
+		"path":  "/foo/bar",
+		"empty": "",
+	}
+	data := []struct {
+		Title string
+		In    string
+		Out   string
+	}{
+		{"A", "/orders/{id}", "/orders/42"},
+		{"B", "/orders{?id,q}", "/orders?id=42&q=hello%20world"},
+		{"C", "/orders?a=1{&id}", "/orders?a=1&id=42"},
+		{"D", "{+path}/here", "/foo/bar/here"},
+		{"E", "{path}", "%2Ffoo%2Fbar"},
+		{"F", "/orders{/id,missing}", "/orders/42"},
+		{"G", "/orders{?missing}", "/orders"},
+		{"H", "/x{;id,empty}", "/x;id=42;empty"},
+		{"I", "/x{.id}{#q}", "/x.42#hello%20world"},
+		{"J", "/orders{?id*}", "/orders?id=42"},
+		{"K", "/broken{id", "/broken{id"},
+	}
+	for _, v := range data {
+		if out := expandURI(v.In, vars); out != v.Out {
+			t.Error("for test", v.Title, "expected", v.Out, "got", out)
+		}
+	}
+}
+
+func TestParseUrlVariables(t *testing.T) {
+	m := urlVariables([]string{"id=42", "q=a=b", "novalue", "=x"})
+	if len(m) != 2 || m["id"] != "42" || m["q"] != "a=b" {
+		t.Error("expected 2 variables got", m)
+	}
+}